@@ -0,0 +1,76 @@
+// gendata 从 crawl 命令生成的快照构建内嵌数据集，之后使用 -tags embeddata 构建的服务器
+// 在实时获取失败或无法启动Chromium时从数据集读取内容
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"genshin-starcraft-mcp/pkg/config"
+	"genshin-starcraft-mcp/pkg/scraper"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run 打包快照并写入数据集文件
+func run(args []string) int {
+	var (
+		name   string
+		output string
+		check  bool
+	)
+	cfg, err := config.LoadWithFlags(args, func(fs *flag.FlagSet) {
+		fs.StringVar(&name, "snapshot", "", "快照的版本名或目录，默认为最新的完整快照")
+		fs.StringVar(&output, "o", scraper.EmbeddedDatasetFile, "数据集输出路径")
+		fs.BoolVar(&check, "check", false, "只检查快照中的导航目录和所有节点类型页面是否都已成功获取，不生成数据集；有缺失时退出码为1")
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	snapshot, err := scraper.ResolveSnapshot(cfg.SnapshotDir, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gendata failed: %v\n", err)
+		return 2
+	}
+	if check {
+		missing := snapshot.MissingCorePages()
+		if len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "snapshot %s is missing %d core pages:\n", snapshot.Manifest.Version, len(missing))
+			for _, page := range missing {
+				fmt.Fprintf(os.Stderr, "  %s\n", page)
+			}
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "snapshot %s has navigation and all node type pages\n", snapshot.Manifest.Version)
+		return 0
+	}
+
+	if failed := snapshot.Manifest.Failed(); failed > 0 {
+		fmt.Fprintf(os.Stderr, "warning: snapshot %s has %d failed pages, they are not included\n", snapshot.Manifest.Version, failed)
+	}
+
+	var buf bytes.Buffer
+	if err := scraper.WriteSnapshotBundle(&buf, snapshot); err != nil {
+		fmt.Fprintf(os.Stderr, "gendata failed: %v\n", err)
+		return 2
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	fmt.Fprintf(os.Stderr, "dataset %s from snapshot %s: %d pages, %d bytes\n", output, snapshot.Manifest.Version, len(snapshot.Manifest.Entries)-snapshot.Manifest.Failed(), buf.Len())
+	return 0
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"genshin-starcraft-mcp/pkg/config"
+	"genshin-starcraft-mcp/pkg/scraper"
+)
+
+// runCache 列出磁盘缓存，或删除、立即刷新单个缓存条目
+func runCache(args []string) int {
+	var (
+		asJSON     bool
+		invalidate string
+		refresh    string
+	)
+	cfg, err := config.LoadWithFlags(args, func(fs *flag.FlagSet) {
+		fs.BoolVar(&asJSON, "json", false, "以JSON格式输出缓存条目")
+		fs.StringVar(&invalidate, "invalidate", "", "删除指定的缓存条目，格式为 种类:key，如 node_graph:服务器节点_执行节点、tutorial:教程ID 或 navigation")
+		fs.StringVar(&refresh, "refresh", "", "立即重新获取指定的缓存条目并写入缓存，格式同 -invalidate")
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	opts := cfg.ScraperOptions()
+	switch {
+	case invalidate != "":
+		kind, key, err := scraper.ParseCacheRef(invalidate)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		if _, err := scraper.InvalidateDiskCache(opts, kind, key); err != nil {
+			fmt.Fprintf(os.Stderr, "invalidate failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("invalidated %s\n", invalidate)
+		return 0
+
+	case refresh != "":
+		kind, key, err := scraper.ParseCacheRef(refresh)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		// 刷新需要获取页面，只在此时创建浏览器
+		browser, err := scraper.NewBrowser(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create browser: %v\n", err)
+			return 2
+		}
+		defer browser.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := browser.RefreshCache(ctx, kind, key); err != nil {
+			fmt.Fprintf(os.Stderr, "refresh failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("refreshed %s\n", refresh)
+		return 0
+	}
+
+	report := scraper.DiskCacheReport(opts)
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	} else {
+		fmt.Print(report.Markdown())
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/config"
+	"genshin-starcraft-mcp/pkg/scraper"
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// runCrawl 抓取全站教程和节点类型页面并写入本地快照，有页面获取失败时返回非零退出码
+func runCrawl(args []string) int {
+	var (
+		version     string
+		resume      bool
+		concurrency int
+		interval    time.Duration
+	)
+	cfg, err := config.LoadWithFlags(args, func(fs *flag.FlagSet) {
+		fs.StringVar(&version, "snapshot", "", "快照版本名，为空时使用当前时间；已存在时续抓未完成的页面")
+		fs.BoolVar(&resume, "resume", false, "续抓最近一个未完成的快照")
+		fs.IntVar(&concurrency, "concurrency", scraper.DefaultCrawlConcurrency, "同时获取的页面数")
+		fs.DurationVar(&interval, "interval", scraper.DefaultCrawlInterval, "相邻两次页面请求的最小间隔，负数表示不限速")
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if resume && version == "" {
+		snapshots, err := scraper.ListSnapshots(cfg.SnapshotDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			if !snapshots[i].Manifest.Complete {
+				version = snapshots[i].Manifest.Version
+				break
+			}
+		}
+		if version == "" {
+			fmt.Fprintf(os.Stderr, "no incomplete snapshot in %s\n", cfg.SnapshotDir)
+			return 2
+		}
+	}
+
+	browser, err := scraper.NewBrowser(cfg.ScraperOptions())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create browser: %v\n", err)
+		return 2
+	}
+	defer browser.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	manifest, err := browser.Crawl(ctx, scraper.CrawlOptions{
+		OutputDir:   cfg.SnapshotDir,
+		Version:     version,
+		Concurrency: concurrency,
+		Interval:    interval,
+		Progress:    printCrawlProgress,
+	})
+	if err != nil {
+		utils.Error("Crawl failed", "error", err)
+		fmt.Fprintf(os.Stderr, "crawl failed: %v\n", err)
+		if manifest != nil {
+			fmt.Fprintf(os.Stderr, "run again with -snapshot %s to resume\n", manifest.Version)
+		}
+		return 2
+	}
+
+	fmt.Fprintf(os.Stderr, "snapshot %s: %d pages, %d failed\n", manifest.Version, len(manifest.Entries), manifest.Failed())
+	if !manifest.Complete {
+		fmt.Fprintf(os.Stderr, "run again with -snapshot %s to retry failed pages\n", manifest.Version)
+		return 1
+	}
+	return 0
+}
+
+// printCrawlProgress 将抓取进度输出到标准错误
+func printCrawlProgress(progress scraper.CrawlProgress) {
+	entry := progress.Entry
+	name := entry.ID
+	switch {
+	case entry.ClientType != "":
+		name = entry.ClientType + "/" + entry.NodeType
+	case entry.Title != "":
+		name = entry.Title + " (" + entry.ID + ")"
+	}
+
+	status := "ok"
+	switch {
+	case progress.Skipped:
+		status = "skipped"
+	case entry.Error != "":
+		status = "failed: " + entry.Error
+	}
+
+	if progress.Total > 0 {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s %s %s\n", progress.Done, progress.Total, entry.Kind, name, status)
+	} else {
+		fmt.Fprintf(os.Stderr, "[%d] %s %s %s\n", progress.Done, entry.Kind, name, status)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"genshin-starcraft-mcp/pkg/config"
+	"genshin-starcraft-mcp/pkg/scraper"
+)
+
+// runDiff 比较两个快照并输出更新日志，有变化时返回退出码1
+func runDiff(args []string) int {
+	var (
+		asJSON bool
+		from   string
+		to     string
+		since  string
+	)
+	cfg, err := config.LoadWithFlags(args, func(fs *flag.FlagSet) {
+		fs.BoolVar(&asJSON, "json", false, "以JSON格式输出更新日志")
+		fs.StringVar(&from, "from", "", "旧快照的版本名或目录，默认为新快照之前的最后一个抓取已结束的快照")
+		fs.StringVar(&to, "to", "", "新快照的版本名或目录，默认为最新的抓取已结束的快照")
+		fs.StringVar(&since, "since", "", "比较该日期之前的最后一个快照与最新的快照（只使用抓取已结束的快照），如 2025-01-01，指定时忽略 -from 和 -to")
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	changelog, err := loadChangelog(cfg.SnapshotDir, from, to, since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "diff failed: %v\n", err)
+		return 2
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(changelog); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	} else {
+		fmt.Print(changelog.Markdown())
+	}
+
+	if !changelog.Empty() {
+		return 1
+	}
+	return 0
+}
+
+// loadChangelog 按命令行参数选择要比较的两个快照
+func loadChangelog(root string, from string, to string, since string) (*scraper.Changelog, error) {
+	if since != "" {
+		date, err := scraper.ParseSince(since)
+		if err != nil {
+			return nil, err
+		}
+		return scraper.ChangelogSince(root, date)
+	}
+
+	var newer *scraper.Snapshot
+	var err error
+	if to != "" {
+		newer, err = scraper.ResolveSnapshot(root, to)
+	} else {
+		newer, err = scraper.LatestComparableSnapshot(root)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var older *scraper.Snapshot
+	if from != "" {
+		older, err = scraper.ResolveSnapshot(root, from)
+	} else {
+		older, err = scraper.PreviousSnapshot(root, newer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return scraper.DiffSnapshots(older, newer)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"genshin-starcraft-mcp/pkg/config"
+	"genshin-starcraft-mcp/pkg/scraper"
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// runHealth 抓取导航目录和所有节点类型页面并检查解析结果，存在问题时返回非零退出码
+func runHealth(args []string) int {
+	var asJSON bool
+	cfg, err := config.LoadWithFlags(args, func(fs *flag.FlagSet) {
+		fs.BoolVar(&asJSON, "json", false, "以JSON格式输出检查报告")
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	browser, err := scraper.NewBrowser(cfg.ScraperOptions())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create browser: %v\n", err)
+		return 2
+	}
+	defer browser.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := browser.CheckParserHealth(ctx)
+	if err != nil {
+		utils.Error("Parser health check failed", "error", err)
+		fmt.Fprintf(os.Stderr, "parser health check failed: %v\n", err)
+		return 2
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	} else {
+		fmt.Print(report.Markdown())
+	}
+
+	if !report.Healthy() {
+		return 1
+	}
+	return 0
+}
@@ -3,22 +3,44 @@ package main
 import (
 	"os"
 
+	"genshin-starcraft-mcp/pkg/config"
 	"genshin-starcraft-mcp/pkg/mcp"
 	"genshin-starcraft-mcp/pkg/utils"
 )
 
 var version = "dev"
 
+// 子命令，未指定子命令时启动MCP服务器
+var commands = map[string]func(args []string) int{
+	"health": runHealth,
+	"crawl":  runCrawl,
+	"diff":   runDiff,
+	"cache":  runCache,
+}
+
 func main() {
 	// 初始化日志系统
 	if err := utils.InitLogger(); err != nil {
 		os.Exit(1)
 	}
 
-	utils.Info("Starting Genshin Starcraft MCP Server...", "version", version)
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
+	// 加载配置
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		utils.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	utils.Info("Starting Genshin Starcraft MCP Server...", "version", version, "fetcher", cfg.Fetcher, "offline", cfg.Offline)
 
 	// 创建MCP服务器
-	server, err := mcp.NewGenshinStarcraftMCPServer(version)
+	server, err := mcp.NewGenshinStarcraftMCPServer(version, cfg)
 	if err != nil {
 		utils.Error("Failed to create MCP server", "error", err)
 		os.Exit(1)
@@ -32,4 +54,4 @@ func main() {
 	}
 
 	utils.Info("Shutting down Star Rail Guide MCP Server...")
-}
\ No newline at end of file
+}
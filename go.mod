@@ -3,11 +3,14 @@ module genshin-starcraft-mcp
 go 1.23.0
 
 require (
+	github.com/PuerkitoBio/goquery v1.10.3
 	github.com/go-rod/rod v0.112.0
 	github.com/mark3labs/mcp-go v0.42.0
+	golang.org/x/net v0.39.0
 )
 
 require (
+	github.com/andybalholm/cascadia v1.3.3 // indirect
 	github.com/bahlo/generic-list-go v0.2.0 // indirect
 	github.com/buger/jsonparser v1.1.1 // indirect
 	github.com/google/uuid v1.6.0 // indirect
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/scraper"
+)
+
+// Config 服务器配置
+type Config struct {
+	ConfigFile string `json:"-"`          // 配置文件路径（JSON）
+	Fetcher    string `json:"fetcher"`    // 页面获取方式：rod（Chromium）或 http（无需Chromium）
+	BaseURL    string `json:"base_url"`   // 官方教程站点地址，可指向本地镜像或缓存代理
+	RecordDir  string `json:"record_dir"` // 录制模式：将访问过的页面DOM保存到该目录
+	ReplayDir  string `json:"replay_dir"` // 回放模式：从该目录读取录制的页面，不访问网络
+	MaxPages   int    `json:"max_pages"`  // rod模式下同时打开的最大页面数
+
+	SnapshotDir string `json:"snapshot_dir"` // crawl 命令写入快照、whats_new 读取快照的根目录
+	CacheDir    string `json:"cache_dir"`    // 磁盘缓存目录，为空时只使用内存缓存
+
+	Offline         bool   `json:"offline"`          // 离线模式：只从快照读取内容，不访问网络也不启动Chromium
+	OfflineSnapshot string `json:"offline_snapshot"` // 离线模式使用的快照版本名或目录，为空时使用最新的完整快照
+
+	WarmUp            bool `json:"warm_up"`             // 启动后在后台预先获取导航目录和所有节点类型页面
+	WarmUpConcurrency int  `json:"warm_up_concurrency"` // 预热时同时获取的页面数
+
+	Retry RetryConfig `json:"retry"` // 页面获取的重试策略
+	Cache CacheConfig `json:"cache"` // 缓存的有效期和后台刷新策略
+
+	ChromeURL         string     `json:"chrome_url"`           // 已运行Chromium的DevTools地址，连接失败时回退到本地启动
+	ChromeBin         string     `json:"chrome_bin"`           // 本地启动时使用的Chromium可执行文件路径
+	ChromeUserDataDir string     `json:"chrome_user_data_dir"` // 本地启动时的用户数据目录
+	ChromeProxy       string     `json:"chrome_proxy"`         // 本地启动时使用的代理
+	ChromeFlags       StringList `json:"chrome_flags"`         // 本地启动时附加的命令行参数
+
+	HealthInterval Duration `json:"health_interval"` // 浏览器健康检查间隔，0 表示关闭定期检查
+
+	NodeTypes scraper.NodeTypes `json:"node_types,omitempty"` // 节点类型到页面ID的映射，覆盖内置和自动发现的映射，仅支持配置文件
+}
+
+// ScraperOptions 转换为抓取模块的浏览器配置
+func (c *Config) ScraperOptions() scraper.Options {
+	retry := c.Retry.Policy()
+	cache := c.Cache.Policy()
+	return scraper.Options{
+		Fetcher:   c.Fetcher,
+		BaseURL:   c.BaseURL,
+		RecordDir: c.RecordDir,
+		ReplayDir: c.ReplayDir,
+		MaxPages:  c.MaxPages,
+		Retry:     &retry,
+		Cache:     &cache,
+		Chrome:    c.ChromeOptions(),
+
+		HealthInterval: time.Duration(c.HealthInterval),
+		NodeTypes:      c.NodeTypes,
+		CacheDir:       c.CacheDir,
+
+		Offline:         c.Offline,
+		SnapshotDir:     c.SnapshotDir,
+		OfflineSnapshot: c.OfflineSnapshot,
+	}
+}
+
+// ChromeOptions 转换为抓取模块使用的Chromium配置
+func (c *Config) ChromeOptions() scraper.ChromeOptions {
+	return scraper.ChromeOptions{
+		ControlURL:  c.ChromeURL,
+		Bin:         c.ChromeBin,
+		UserDataDir: c.ChromeUserDataDir,
+		Proxy:       c.ChromeProxy,
+		Flags:       c.ChromeFlags,
+	}
+}
+
+// RetryConfig 重试策略配置
+type RetryConfig struct {
+	MaxAttempts    int      `json:"max_attempts"`    // 最大尝试次数（含首次）
+	InitialBackoff Duration `json:"initial_backoff"` // 首次重试前的等待时间
+	MaxBackoff     Duration `json:"max_backoff"`     // 单次等待时间上限
+	Multiplier     float64  `json:"multiplier"`      // 每次重试等待时间的倍数
+	Jitter         float64  `json:"jitter"`          // 随机抖动比例
+	Budget         Duration `json:"budget"`          // 单次操作的总时间预算
+}
+
+// Policy 转换为抓取模块使用的重试策略
+func (c RetryConfig) Policy() scraper.RetryPolicy {
+	return scraper.RetryPolicy{
+		MaxAttempts:    c.MaxAttempts,
+		InitialBackoff: time.Duration(c.InitialBackoff),
+		MaxBackoff:     time.Duration(c.MaxBackoff),
+		Multiplier:     c.Multiplier,
+		Jitter:         c.Jitter,
+		Budget:         time.Duration(c.Budget),
+	}
+}
+
+// CacheConfig 缓存策略配置
+type CacheConfig struct {
+	NavigationTTL   Duration `json:"navigation_ttl"`   // 导航目录的有效期
+	TutorialTTL     Duration `json:"tutorial_ttl"`     // 教程的有效期
+	NodeGraphTTL    Duration `json:"node_graph_ttl"`   // 节点类型页面的有效期
+	MaxStale        Duration `json:"max_stale"`        // 过期后仍可使用并在后台刷新的时长
+	RefreshInterval Duration `json:"refresh_interval"` // 后台定期刷新的间隔
+	MaxMemoryMB     int      `json:"max_memory_mb"`    // 内存缓存的大小上限（MB）
+}
+
+// Policy 转换为抓取模块使用的缓存策略
+func (c CacheConfig) Policy() scraper.CachePolicy {
+	return scraper.CachePolicy{
+		NavigationTTL:   time.Duration(c.NavigationTTL),
+		TutorialTTL:     time.Duration(c.TutorialTTL),
+		NodeGraphTTL:    time.Duration(c.NodeGraphTTL),
+		MaxStale:        time.Duration(c.MaxStale),
+		RefreshInterval: time.Duration(c.RefreshInterval),
+		MaxMemory:       int64(c.MaxMemoryMB) << 20,
+	}
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		Fetcher:  scraper.FetcherRod,
+		BaseURL:  scraper.DefaultBaseURL,
+		MaxPages: scraper.DefaultMaxPages,
+		Retry:    retryConfigOf(scraper.DefaultRetryPolicy()),
+		Cache:    cacheConfigOf(scraper.DefaultCachePolicy()),
+
+		SnapshotDir: scraper.DefaultSnapshotDir,
+		CacheDir:    scraper.DefaultCacheDir(),
+
+		WarmUpConcurrency: scraper.DefaultWarmUpConcurrency,
+
+		HealthInterval: Duration(scraper.DefaultHealthInterval),
+	}
+}
+
+// retryConfigOf 由重试策略生成配置
+func retryConfigOf(p scraper.RetryPolicy) RetryConfig {
+	return RetryConfig{
+		MaxAttempts:    p.MaxAttempts,
+		InitialBackoff: Duration(p.InitialBackoff),
+		MaxBackoff:     Duration(p.MaxBackoff),
+		Multiplier:     p.Multiplier,
+		Jitter:         p.Jitter,
+		Budget:         Duration(p.Budget),
+	}
+}
+
+// cacheConfigOf 由缓存策略生成配置
+func cacheConfigOf(p scraper.CachePolicy) CacheConfig {
+	return CacheConfig{
+		NavigationTTL:   Duration(p.NavigationTTL),
+		TutorialTTL:     Duration(p.TutorialTTL),
+		NodeGraphTTL:    Duration(p.NodeGraphTTL),
+		MaxStale:        Duration(p.MaxStale),
+		RefreshInterval: Duration(p.RefreshInterval),
+		MaxMemoryMB:     int(p.MaxMemory >> 20),
+	}
+}
+
+// Load 加载配置，优先级：命令行参数 > 环境变量 > 配置文件 > 默认值
+func Load(args []string) (*Config, error) {
+	return LoadWithFlags(args, nil)
+}
+
+// LoadWithFlags 加载配置，extra 不为空时用于注册子命令自身的命令行参数
+func LoadWithFlags(args []string, extra func(fs *flag.FlagSet)) (*Config, error) {
+	// 第一遍解析仅用于找到配置文件路径
+	pre := &Config{ConfigFile: os.Getenv("GSM_CONFIG")}
+	preFlags := newFlagSet(pre)
+	preFlags.SetOutput(io.Discard)
+	if extra != nil {
+		extra(preFlags)
+	}
+	if err := preFlags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	cfg.ConfigFile = pre.ConfigFile
+	if cfg.ConfigFile != "" {
+		if err := loadFile(cfg, cfg.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	// 第二遍解析，命令行参数覆盖前面的配置
+	fs := newFlagSet(cfg)
+	if extra != nil {
+		extra(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// newFlagSet 创建绑定到配置的命令行参数集，参数默认值取配置当前值
+func newFlagSet(cfg *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("genshin-starcraft-mcp", flag.ContinueOnError)
+	fs.StringVar(&cfg.ConfigFile, "config", cfg.ConfigFile, "JSON配置文件路径，对应环境变量 GSM_CONFIG")
+	fs.StringVar(&cfg.Fetcher, "fetcher", cfg.Fetcher, "页面获取方式：rod 或 http，对应环境变量 GSM_FETCHER")
+	fs.StringVar(&cfg.BaseURL, "base-url", cfg.BaseURL, "官方教程站点地址，对应环境变量 GSM_BASE_URL")
+	fs.StringVar(&cfg.RecordDir, "record", cfg.RecordDir, "录制模式，将访问过的页面保存到该目录，对应环境变量 GSM_RECORD_DIR")
+	fs.StringVar(&cfg.ReplayDir, "replay", cfg.ReplayDir, "回放模式，从该目录读取录制的页面，对应环境变量 GSM_REPLAY_DIR")
+	fs.StringVar(&cfg.SnapshotDir, "snapshot-dir", cfg.SnapshotDir, "快照根目录，对应环境变量 GSM_SNAPSHOT_DIR")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", cfg.CacheDir, "磁盘缓存目录，设为空字符串时只使用内存缓存，对应环境变量 GSM_CACHE_DIR")
+	fs.Var(&cfg.Cache.NavigationTTL, "navigation-ttl", "导航目录缓存的有效期，对应环境变量 GSM_NAVIGATION_TTL")
+	fs.Var(&cfg.Cache.TutorialTTL, "guide-ttl", "教程缓存的有效期，对应环境变量 GSM_GUIDE_TTL")
+	fs.Var(&cfg.Cache.NodeGraphTTL, "node-ttl", "节点类型页面缓存的有效期，对应环境变量 GSM_NODE_TTL")
+	fs.Var(&cfg.Cache.MaxStale, "cache-max-stale", "缓存过期后仍直接返回并在后台刷新的时长，0 表示过期后同步获取，对应环境变量 GSM_CACHE_MAX_STALE")
+	fs.Var(&cfg.Cache.RefreshInterval, "cache-refresh-interval", "后台定期刷新即将过期且近期被读取过的缓存的间隔，0 表示关闭，对应环境变量 GSM_CACHE_REFRESH_INTERVAL")
+	fs.IntVar(&cfg.Cache.MaxMemoryMB, "cache-max-memory", cfg.Cache.MaxMemoryMB, "内存缓存的大小上限（MB），超出时淘汰最久未使用的条目，0 表示不限制，对应环境变量 GSM_CACHE_MAX_MEMORY")
+	fs.BoolVar(&cfg.Offline, "offline", cfg.Offline, "离线模式，只从快照目录读取内容，不访问网络也不启动Chromium，对应环境变量 GSM_OFFLINE")
+	fs.StringVar(&cfg.OfflineSnapshot, "offline-snapshot", cfg.OfflineSnapshot, "离线模式使用的快照版本名或目录，默认为最新的完整快照，对应环境变量 GSM_OFFLINE_SNAPSHOT")
+	fs.BoolVar(&cfg.WarmUp, "warm-up", cfg.WarmUp, "启动后在后台预先获取导航目录和所有节点类型页面，对应环境变量 GSM_WARM_UP")
+	fs.IntVar(&cfg.WarmUpConcurrency, "warm-up-concurrency", cfg.WarmUpConcurrency, "预热时同时获取的页面数，对应环境变量 GSM_WARM_UP_CONCURRENCY")
+	fs.IntVar(&cfg.MaxPages, "max-pages", cfg.MaxPages, "同时打开的最大页面数，对应环境变量 GSM_MAX_PAGES")
+	fs.IntVar(&cfg.Retry.MaxAttempts, "retry-attempts", cfg.Retry.MaxAttempts, "页面获取的最大尝试次数，1 表示不重试，对应环境变量 GSM_RETRY_ATTEMPTS")
+	fs.Var(&cfg.Retry.InitialBackoff, "retry-backoff", "首次重试前的等待时间，对应环境变量 GSM_RETRY_BACKOFF")
+	fs.Var(&cfg.Retry.MaxBackoff, "retry-max-backoff", "重试等待时间上限，对应环境变量 GSM_RETRY_MAX_BACKOFF")
+	fs.Var(&cfg.Retry.Budget, "retry-budget", "单次页面获取含重试的总时间预算，对应环境变量 GSM_RETRY_BUDGET")
+	fs.StringVar(&cfg.ChromeURL, "chrome-url", cfg.ChromeURL, "已运行Chromium的DevTools地址，如 ws://host:9222/devtools/browser/xxx 或 http://host:9222，对应环境变量 GSM_CHROME_URL")
+	fs.StringVar(&cfg.ChromeBin, "chrome-bin", cfg.ChromeBin, "本地启动时使用的Chromium可执行文件路径，对应环境变量 GSM_CHROME_BIN")
+	fs.StringVar(&cfg.ChromeUserDataDir, "chrome-user-data-dir", cfg.ChromeUserDataDir, "本地启动时的用户数据目录，对应环境变量 GSM_CHROME_USER_DATA_DIR")
+	fs.StringVar(&cfg.ChromeProxy, "chrome-proxy", cfg.ChromeProxy, "本地启动时使用的代理，如 127.0.0.1:8080，对应环境变量 GSM_CHROME_PROXY")
+	fs.Var(&cfg.HealthInterval, "health-interval", "浏览器健康检查间隔，浏览器退出或卡死时自动重启，0 表示关闭定期检查，对应环境变量 GSM_HEALTH_INTERVAL")
+	fs.Var(&stringListFlag{list: &cfg.ChromeFlags}, "chrome-flag", "本地启动时附加的命令行参数，可重复指定并覆盖配置文件和环境变量中的参数，如 -chrome-flag=disable-gpu，对应环境变量 GSM_CHROME_FLAGS（空格分隔）")
+	return fs
+}
+
+// loadFile 从JSON配置文件加载配置
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv 应用环境变量中的配置
+func applyEnv(cfg *Config) error {
+	cfg.Fetcher = envOr("GSM_FETCHER", cfg.Fetcher)
+	cfg.BaseURL = envOr("GSM_BASE_URL", cfg.BaseURL)
+	cfg.RecordDir = envOr("GSM_RECORD_DIR", cfg.RecordDir)
+	cfg.ReplayDir = envOr("GSM_REPLAY_DIR", cfg.ReplayDir)
+	cfg.SnapshotDir = envOr("GSM_SNAPSHOT_DIR", cfg.SnapshotDir)
+	cfg.OfflineSnapshot = envOr("GSM_OFFLINE_SNAPSHOT", cfg.OfflineSnapshot)
+	if value, ok := os.LookupEnv("GSM_CACHE_DIR"); ok {
+		// 允许设为空字符串关闭磁盘缓存
+		cfg.CacheDir = value
+	}
+	cfg.ChromeURL = envOr("GSM_CHROME_URL", cfg.ChromeURL)
+	cfg.ChromeBin = envOr("GSM_CHROME_BIN", cfg.ChromeBin)
+	cfg.ChromeUserDataDir = envOr("GSM_CHROME_USER_DATA_DIR", cfg.ChromeUserDataDir)
+	cfg.ChromeProxy = envOr("GSM_CHROME_PROXY", cfg.ChromeProxy)
+	if value := os.Getenv("GSM_CHROME_FLAGS"); value != "" {
+		cfg.ChromeFlags = strings.Fields(value)
+	}
+
+	var err error
+	if cfg.MaxPages, err = envInt("GSM_MAX_PAGES", cfg.MaxPages); err != nil {
+		return err
+	}
+	if cfg.Offline, err = envBool("GSM_OFFLINE", cfg.Offline); err != nil {
+		return err
+	}
+	if cfg.WarmUp, err = envBool("GSM_WARM_UP", cfg.WarmUp); err != nil {
+		return err
+	}
+	if cfg.WarmUpConcurrency, err = envInt("GSM_WARM_UP_CONCURRENCY", cfg.WarmUpConcurrency); err != nil {
+		return err
+	}
+	if cfg.Retry.MaxAttempts, err = envInt("GSM_RETRY_ATTEMPTS", cfg.Retry.MaxAttempts); err != nil {
+		return err
+	}
+	if cfg.Cache.MaxMemoryMB, err = envInt("GSM_CACHE_MAX_MEMORY", cfg.Cache.MaxMemoryMB); err != nil {
+		return err
+	}
+	if err := envDuration("GSM_RETRY_BACKOFF", &cfg.Retry.InitialBackoff); err != nil {
+		return err
+	}
+	if err := envDuration("GSM_RETRY_MAX_BACKOFF", &cfg.Retry.MaxBackoff); err != nil {
+		return err
+	}
+	if err := envDuration("GSM_RETRY_BUDGET", &cfg.Retry.Budget); err != nil {
+		return err
+	}
+	if err := envDuration("GSM_HEALTH_INTERVAL", &cfg.HealthInterval); err != nil {
+		return err
+	}
+	if err := envDuration("GSM_NAVIGATION_TTL", &cfg.Cache.NavigationTTL); err != nil {
+		return err
+	}
+	if err := envDuration("GSM_GUIDE_TTL", &cfg.Cache.TutorialTTL); err != nil {
+		return err
+	}
+	if err := envDuration("GSM_NODE_TTL", &cfg.Cache.NodeGraphTTL); err != nil {
+		return err
+	}
+	if err := envDuration("GSM_CACHE_MAX_STALE", &cfg.Cache.MaxStale); err != nil {
+		return err
+	}
+	if err := envDuration("GSM_CACHE_REFRESH_INTERVAL", &cfg.Cache.RefreshInterval); err != nil {
+		return err
+	}
+	return nil
+}
+
+// envOr 读取环境变量，未设置时返回默认值
+func envOr(key string, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// envInt 读取整数环境变量，未设置时返回默认值
+func envInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s=%q: %w", key, value, err)
+	}
+	return n, nil
+}
+
+// envBool 读取布尔环境变量，未设置时返回默认值
+func envBool(key string, def bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s=%q: %w", key, value, err)
+	}
+	return b, nil
+}
+
+// envDuration 读取时长环境变量，未设置时保留原值
+func envDuration(key string, d *Duration) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	if err := d.Set(value); err != nil {
+		return fmt.Errorf("invalid %s=%q: %w", key, value, err)
+	}
+	return nil
+}
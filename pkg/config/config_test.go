@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestChromeFlagsPrecedence 命令行参数 > 环境变量 > 配置文件，重复的 -chrome-flag 替换而不是追加到之前的列表
+func TestChromeFlagsPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"chrome_flags": ["from-file"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		env  string
+		args []string
+		want []string
+	}{
+		{name: "file", want: []string{"from-file"}},
+		{name: "env overrides file", env: "env-a env-b", want: []string{"env-a", "env-b"}},
+		{name: "flags override env", env: "env-a", args: []string{"-chrome-flag=cli-a", "-chrome-flag", "cli-b"}, want: []string{"cli-a", "cli-b"}},
+		{name: "flags override file", args: []string{"-chrome-flag=cli-a"}, want: []string{"cli-a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GSM_CONFIG", file)
+			t.Setenv("GSM_CHROME_FLAGS", tt.env)
+
+			cfg, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !slices.Equal(cfg.ChromeFlags, tt.want) {
+				t.Errorf("ChromeFlags = %v, want %v", cfg.ChromeFlags, tt.want)
+			}
+		})
+	}
+}
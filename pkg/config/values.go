@@ -0,0 +1,84 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Duration 时长配置，JSON和命令行参数中使用 "500ms"、"2s"、"1m" 等格式
+type Duration time.Duration
+
+// Set 实现 flag.Value
+func (d *Duration) Set(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// String 实现 flag.Value
+func (d *Duration) String() string {
+	if d == nil {
+		return "0s"
+	}
+	return time.Duration(*d).String()
+}
+
+// MarshalJSON 输出为时长字符串
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON 解析时长字符串
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"2s\": %w", err)
+	}
+	return d.Set(value)
+}
+
+// StringList 可重复指定的字符串列表参数
+type StringList []string
+
+// Set 实现 flag.Value，每次指定追加一项
+func (l *StringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// String 实现 flag.Value
+func (l *StringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, " ")
+}
+
+// stringListFlag 命令行中的字符串列表参数，第一次指定时清空来自配置文件和环境变量的列表，
+// 使命令行参数覆盖而不是追加到之前的配置
+type stringListFlag struct {
+	list *StringList
+	set  bool
+}
+
+// Set 实现 flag.Value
+func (f *stringListFlag) Set(value string) error {
+	if !f.set {
+		*f.list = nil
+		f.set = true
+	}
+	return f.list.Set(value)
+}
+
+// String 实现 flag.Value
+func (f *stringListFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.list.String()
+}
@@ -2,28 +2,52 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"genshin-starcraft-mcp/pkg/config"
 	"genshin-starcraft-mcp/pkg/models"
 	"genshin-starcraft-mcp/pkg/scraper"
 	"genshin-starcraft-mcp/pkg/utils"
 )
 
+// get_guide 和 whats_new 的返回格式
+const (
+	guideFormatMarkdown = "markdown"
+	guideFormatJSON     = "json"
+)
+
+// cache 工具的操作
+const (
+	cacheActionList       = "list"
+	cacheActionInvalidate = "invalidate"
+	cacheActionRefresh    = "refresh"
+)
+
+// 启动时后台发现节点类型的超时时间
+const nodeTypeDiscoveryTimeout = 3 * time.Minute
+
 // GenshinStarcraftMCPServer 使用官方MCP库的服务器
 type GenshinStarcraftMCPServer struct {
 	browser *scraper.Browser
 	server  *server.MCPServer
 	version string
+	cancel  context.CancelFunc // 取消后台任务
+
+	snapshotDir string // whats_new 读取快照的根目录
 }
 
 // NewGenshinStarcraftMCPServer 创建新的MCP服务器
-func NewGenshinStarcraftMCPServer(version string) (*GenshinStarcraftMCPServer, error) {
+func NewGenshinStarcraftMCPServer(version string, cfg *config.Config) (*GenshinStarcraftMCPServer, error) {
 	utils.Debug("Creating new MCP server with official library", "version", version)
 
-	browser, err := scraper.NewBrowser()
+	browser, err := scraper.NewBrowser(cfg.ScraperOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create browser: %w", err)
 	}
@@ -32,8 +56,9 @@ func NewGenshinStarcraftMCPServer(version string) (*GenshinStarcraftMCPServer, e
 	s := server.NewMCPServer(
 		"原神千星奇域教程",
 		"1.0.0",
-		server.WithToolCapabilities(false),
+		server.WithToolCapabilities(true), // 发现新的节点类型后更新工具描述并通知客户端
 		server.WithRecovery(),
+		server.WithToolHandlerMiddleware(recordContentSource),
 	)
 
 	// // 添加搜索工具
@@ -47,18 +72,94 @@ func NewGenshinStarcraftMCPServer(version string) (*GenshinStarcraftMCPServer, e
 
 	// 添加导航工具
 	navigationTool := mcp.NewTool("get_navigation",
-		mcp.WithDescription("获取原神千星奇域综合指南网站的完整导航目录，返回所有可用的教程分类和章节链接列表。"),
+		mcp.WithDescription("获取原神千星奇域综合指南网站的导航目录，按 分类 → 子分类 → 页面 的层级返回教程链接，链接中为教程ID。可按分类名或层数只返回部分目录。"),
+		mcp.WithString("category",
+			mcp.Description("只返回指定分类（或子分类）下的目录，支持部分匹配，例如'节点图'"),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description("最多返回的层数，例如1表示只返回顶级分类，默认不限制"),
+		),
 	)
 
 	// 添加指南工具
 	tutorialTool := mcp.NewTool("get_guide",
-		mcp.WithDescription("根据导航目录中的ID获取具体的教程内容，包括节点功能说明、参数表格、使用方法、配置说明等详细信息。"),
+		mcp.WithDescription("根据导航目录中的ID获取具体的教程内容（Markdown格式，保留标题层级、列表、表格和链接），包括节点功能说明、参数表格、使用方法、配置说明等详细信息。"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("教程页面ID，例如'mh29wpicgvh0'，从get_navigation工具返回的导航列表中获取"),
+		),
+		mcp.WithString("format",
+			mcp.Description("返回格式：'markdown'（默认）返回Markdown正文；'json' 返回结构化教程，包含摘要、章节树（含章节ID）和表格"),
+			mcp.Enum(guideFormatMarkdown, guideFormatJSON),
+		),
+	)
+
+	// 添加指南目录工具
+	guideOutlineTool := mcp.NewTool("get_guide_outline",
+		mcp.WithDescription("获取教程的摘要和标题目录（章节树及章节ID），不返回正文。教程较长时建议先调用本工具，再用get_guide_section按需获取章节内容。"),
 		mcp.WithString("id",
 			mcp.Required(),
 			mcp.Description("教程页面ID，例如'mh29wpicgvh0'，从get_navigation工具返回的导航列表中获取"),
 		),
 	)
 
+	// 添加指南章节工具
+	guideSectionTool := mcp.NewTool("get_guide_section",
+		mcp.WithDescription("获取教程中的单个章节内容（Markdown格式），可通过章节ID或标题定位。"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("教程页面ID，例如'mh29wpicgvh0'，从get_navigation工具返回的导航列表中获取"),
+		),
+		mcp.WithString("section",
+			mcp.Required(),
+			mcp.Description("章节ID或标题，从get_guide_outline工具返回的目录中获取；标题支持部分匹配"),
+		),
+		mcp.WithBoolean("include_children",
+			mcp.Description("是否同时返回所有子章节的内容，默认false，只列出子章节目录"),
+		),
+	)
+
+	// 添加解析器健康检查工具
+	parserHealthTool := mcp.NewTool("parser_health",
+		mcp.WithDescription("检查网页解析器是否仍与官方页面结构匹配，返回各页面解析结果违反预期（节点数量、参数表头、节点描述等）的问题列表。工具返回异常结果时可用于判断是否是官方页面改版导致。"),
+		mcp.WithBoolean("run",
+			mcp.Description("是否立即重新抓取导航目录和所有节点类型页面进行检查（较慢），默认只返回最近解析过的页面的检查结果"),
+		),
+	)
+
+	// 添加更新日志工具
+	whatsNewTool := mcp.NewTool("whats_new",
+		mcp.WithDescription("报告指定日期以来官方教程和节点的变化：新增、移除和改名的节点，入参出参的名称或类型变化，节点描述变化，以及新增、移除和内容更新的教程。基于 crawl 命令定期生成的本地快照。"),
+		mcp.WithString("since",
+			mcp.Required(),
+			mcp.Description("起始日期，格式 YYYY-MM-DD，例如'2025-01-01'；与该日期之前的最后一个快照比较"),
+		),
+		mcp.WithString("format",
+			mcp.Description("返回格式：'markdown'（默认）或 'json'（结构化的变化列表）"),
+			mcp.Enum(guideFormatMarkdown, guideFormatJSON),
+		),
+	)
+
+	// 添加缓存管理工具
+	cacheTool := mcp.NewTool("cache",
+		mcp.WithDescription("查看和管理服务器缓存：列出内存和磁盘缓存中的导航目录、教程和节点类型页面及其缓存时长、大小和命中计数，删除或立即刷新单个条目。官方页面已更新但工具仍返回旧内容时使用。"),
+		mcp.WithString("action",
+			mcp.Description("操作：'list'（默认，列出缓存条目和命中计数）、'invalidate'（删除条目，下次请求时重新获取）或 'refresh'（立即重新获取）"),
+			mcp.Enum(cacheActionList, cacheActionInvalidate, cacheActionRefresh),
+		),
+		mcp.WithString("kind",
+			mcp.Description("invalidate 和 refresh 操作的条目种类，与 list 结果中的种类一致：'navigation'（导航目录）、'tutorial'（教程）或 'node_graph'（节点类型页面）"),
+			mcp.Enum(scraper.PageKindNavigation, scraper.PageKindTutorial, scraper.PageKindNodeGraph),
+		),
+		mcp.WithString("key",
+			mcp.Description("invalidate 和 refresh 操作的缓存key：节点类型页面为 客户端类型_节点类型（例如'服务器节点_执行节点'），教程为教程ID，导航目录可省略"),
+		),
+		mcp.WithString("format",
+			mcp.Description("list 操作的返回格式：'markdown'（默认）或 'json'"),
+			mcp.Enum(guideFormatMarkdown, guideFormatJSON),
+		),
+	)
+
 	// // 添加打开搜索结果工具
 	// openSearchTool := mcp.NewTool("open_search_result",
 	// 	mcp.WithDescription("根据搜索结果中的标题直接打开对应的教程页面，获取完整的教程内容。"),
@@ -68,16 +169,74 @@ func NewGenshinStarcraftMCPServer(version string) (*GenshinStarcraftMCPServer, e
 	// 	),
 	// )
 
+	ctx, cancel := context.WithCancel(context.Background())
+	genshinServer := &GenshinStarcraftMCPServer{
+		browser: browser,
+		server:  s,
+		version: version,
+		cancel:  cancel,
+
+		snapshotDir: cfg.SnapshotDir,
+	}
+
+	// 添加工具处理器
+	// s.AddTool(searchTool, genshinServer.handleSearch)
+	s.AddTool(navigationTool, genshinServer.handleGetNavigation)
+	s.AddTool(tutorialTool, genshinServer.handleGetGuide)
+	s.AddTool(guideOutlineTool, genshinServer.handleGetGuideOutline)
+	s.AddTool(guideSectionTool, genshinServer.handleGetGuideSection)
+	// s.AddTool(openSearchTool, genshinServer.handleOpenSearchResult)
+	s.AddTool(parserHealthTool, genshinServer.handleParserHealth)
+	s.AddTool(whatsNewTool, genshinServer.handleWhatsNew)
+	s.AddTool(cacheTool, genshinServer.handleCache)
+
+	// 节点图工具的描述中列出当前已知的节点类型，启动后在后台从导航目录发现新的节点类型，
+	// 开启预热时再预先获取所有节点类型页面，不阻塞stdio握手
+	nodeTypes := browser.KnownNodeTypes()
+	genshinServer.addNodeGraphTools(nodeTypes)
+	go func() {
+		genshinServer.discoverNodeTypes(ctx, nodeTypes)
+		if cfg.WarmUp {
+			browser.WarmUp(ctx, cfg.WarmUpConcurrency)
+		}
+	}()
+
+	utils.Debug("MCP server created successfully with official library", "version", version)
+	return genshinServer, nil
+}
+
+// Close 关闭服务器
+func (s *GenshinStarcraftMCPServer) Close() error {
+	utils.Debug("Closing MCP server")
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.browser != nil {
+		return s.browser.Close()
+	}
+	return nil
+}
+
+// addNodeGraphTools 注册节点图工具，参数说明中列出可选的客户端类型和节点类型；重复注册时替换原有工具
+func (s *GenshinStarcraftMCPServer) addNodeGraphTools(nodeTypes scraper.NodeTypes) {
+	clientTypes := nodeTypes.ClientTypes()
+	quoted := make([]string, 0, len(clientTypes))
+	for _, clientType := range clientTypes {
+		quoted = append(quoted, fmt.Sprintf("'%s'", clientType))
+	}
+	clientTypeDesc := fmt.Sprintf("客户端类型：%s", strings.Join(quoted, "或 "))
+	nodeTypeDesc := describeNodeTypes(nodeTypes)
+
 	// 添加获取节点图列表工具
 	nodeGraphsTool := mcp.NewTool("get_node_graphs",
 		mcp.WithDescription("获取指定类型的千星奇域节点图列表，返回该类型下所有可用的节点名称和功能描述。结果会被缓存以提高查询效率。"),
 		mcp.WithString("client_type",
 			mcp.Required(),
-			mcp.Description("客户端类型：'客户端节点'或 '服务器节点'"),
+			mcp.Description(clientTypeDesc),
 		),
 		mcp.WithString("node_type",
 			mcp.Required(),
-			mcp.Description("节点类型。客户端节点可选：查询节点/运算节点/执行节点/流程控制节点/其它节点；服务器节点可选：执行节点/事件节点/流程控制节点/查询节点/运算节点"),
+			mcp.Description("节点类型。"+nodeTypeDesc),
 		),
 	)
 
@@ -86,11 +245,11 @@ func NewGenshinStarcraftMCPServer(version string) (*GenshinStarcraftMCPServer, e
 		mcp.WithDescription("获取指定节点的详细信息，包括参数表格、输入输出说明、使用示例等。会利用get_node_graphs工具的缓存数据提高效率。"),
 		mcp.WithString("client_type",
 			mcp.Required(),
-			mcp.Description("客户端类型：'客户端节点'或 '服务器节点'，用于准确定位节点"),
+			mcp.Description(clientTypeDesc+"，用于准确定位节点"),
 		),
 		mcp.WithString("node_type",
 			mcp.Required(),
-			mcp.Description("节点类型，用于准确定位节点。客户端节点可选：查询节点/运算节点/执行节点/流程控制节点/其它节点；服务器节点可选：执行节点/事件节点/流程控制节点/查询节点/运算节点"),
+			mcp.Description("节点类型，用于准确定位节点。"+nodeTypeDesc),
 		),
 		mcp.WithString("node_name",
 			mcp.Required(),
@@ -98,31 +257,34 @@ func NewGenshinStarcraftMCPServer(version string) (*GenshinStarcraftMCPServer, e
 		),
 	)
 
-	genshinServer := &GenshinStarcraftMCPServer{
-		browser: browser,
-		server:  s,
-		version: version,
-	}
-
-	// 添加工具处理器
-	// s.AddTool(searchTool, genshinServer.handleSearch)
-	s.AddTool(navigationTool, genshinServer.handleGetNavigation)
-	s.AddTool(tutorialTool, genshinServer.handleGetGuide)
-	// s.AddTool(openSearchTool, genshinServer.handleOpenSearchResult)
-	s.AddTool(nodeGraphsTool, genshinServer.handleGetNodeGraphs)
-	s.AddTool(nodeGraphDetailsTool, genshinServer.handleGetNodeGraphDetails)
+	s.server.AddTools(
+		server.ServerTool{Tool: nodeGraphsTool, Handler: s.handleGetNodeGraphs},
+		server.ServerTool{Tool: nodeGraphDetailsTool, Handler: s.handleGetNodeGraphDetails},
+	)
+}
 
-	utils.Debug("MCP server created successfully with official library", "version", version)
-	return genshinServer, nil
+// describeNodeTypes 生成节点类型参数的可选值说明，如 客户端节点可选：查询节点/运算节点；服务器节点可选：执行节点
+func describeNodeTypes(nodeTypes scraper.NodeTypes) string {
+	var parts []string
+	for _, clientType := range nodeTypes.ClientTypes() {
+		parts = append(parts, fmt.Sprintf("%s可选：%s", clientType, strings.Join(nodeTypes.Types(clientType), "/")))
+	}
+	return strings.Join(parts, "；")
 }
 
-// Close 关闭服务器
-func (s *GenshinStarcraftMCPServer) Close() error {
-	utils.Debug("Closing MCP server")
-	if s.browser != nil {
-		return s.browser.Close()
+// discoverNodeTypes 从导航目录发现节点类型，与 known 不同时重新注册节点图工具，客户端会收到工具列表变化的通知
+func (s *GenshinStarcraftMCPServer) discoverNodeTypes(ctx context.Context, known scraper.NodeTypes) {
+	ctx, cancel := context.WithTimeout(ctx, nodeTypeDiscoveryTimeout)
+	defer cancel()
+
+	nodeTypes := s.browser.NodeTypes(ctx)
+	if nodeTypes.Equal(known) {
+		utils.Debug("No new node types discovered")
+		return
 	}
-	return nil
+
+	s.addNodeGraphTools(nodeTypes)
+	utils.Info("Node graph tools updated with discovered node types", "node_types", describeNodeTypes(nodeTypes))
 }
 
 // Start 启动MCP服务器
@@ -131,6 +293,65 @@ func (s *GenshinStarcraftMCPServer) Start() error {
 	return server.ServeStdio(s.server)
 }
 
+// toolError 将抓取错误转换为工具错误结果，按错误类型给出可操作的提示，请求取消与失败分开提示
+func toolError(action string, err error) *mcp.CallToolResult {
+	var hint string
+	switch {
+	case errors.Is(err, context.Canceled):
+		utils.Info("Tool call canceled", "action", action)
+		return mcp.NewToolResultError(fmt.Sprintf("%s已取消: 请求被客户端取消", action))
+	case errors.Is(err, scraper.ErrNodeNotFound):
+		hint = "未找到该节点，请先调用 get_node_graphs 获取准确的节点名称后重试"
+	case errors.Is(err, scraper.ErrSectionNotFound):
+		hint = "未找到该章节，请先调用 get_guide_outline 获取章节ID后重试"
+	case errors.Is(err, scraper.ErrUnknownNodeType):
+		hint = "不支持的节点类型，请检查 client_type 和 node_type 参数是否为可选值之一"
+	case errors.Is(err, scraper.ErrSelectorMissing):
+		hint = "官方页面结构可能已变化，未找到预期的页面元素，请稍后重试或反馈问题"
+	case errors.Is(err, scraper.ErrNavigationTimeout), errors.Is(err, context.DeadlineExceeded):
+		hint = "官方网站响应超时，请稍后重试"
+	case errors.Is(err, scraper.ErrSnapshotNotFound):
+		hint = "没有可比较的快照，请先定期运行 crawl 命令生成快照"
+	case errors.Is(err, scraper.ErrNotInSnapshot):
+		hint = "离线模式下只能查询快照中的内容，快照中没有该页面，请用 get_navigation 查看快照中的教程"
+	case errors.Is(err, scraper.ErrCacheEntryNotFound):
+		hint = "缓存中没有该条目，请先调用 cache 工具列出缓存条目的种类和key"
+	case errors.Is(err, scraper.ErrInvalidCacheKey):
+		hint = "缓存条目的种类必须为 navigation、tutorial 或 node_graph，教程和节点类型页面还需要指定 key"
+	case errors.Is(err, scraper.ErrBrowserUnavailable):
+		hint = "浏览器不可用，请确认本机已安装Chromium，或使用 -fetcher http 启动服务器"
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("%s失败: %v", action, err))
+	}
+
+	utils.Info("Tool call failed", "action", action, "error", err)
+	return mcp.NewToolResultError(fmt.Sprintf("%s失败: %s\n详细信息: %v", action, hint, err))
+}
+
+// recordContentSource 为每次工具调用记录内容来源，withSnapshotNote 据此标注来自快照的内容
+func recordContentSource(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return next(scraper.WithContentSource(ctx), request)
+	}
+}
+
+// withSnapshotNote 内容来自离线快照或内嵌数据集时在结果后附加快照的抓取时间，提醒内容可能不是最新的
+func (s *GenshinStarcraftMCPServer) withSnapshotNote(ctx context.Context, result *mcp.CallToolResult) *mcp.CallToolResult {
+	source := scraper.ContentSourceFrom(ctx)
+	if source == nil {
+		return result
+	}
+
+	manifest := source.Manifest
+	date := manifest.StartedAt.Local().Format(time.DateTime)
+	note := fmt.Sprintf("离线模式：以上内容来自 %s 抓取的快照 %s，可能不是官方网站的最新内容", date, manifest.Version)
+	if source.Embedded {
+		note = fmt.Sprintf("官方网站获取失败：以上内容来自内嵌数据集（%s 抓取的快照 %s），可能不是官方网站的最新内容", date, manifest.Version)
+	}
+	result.Content = append(result.Content, mcp.NewTextContent(note))
+	return result
+}
+
 // handleSearch 处理搜索请求
 func (s *GenshinStarcraftMCPServer) handleSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	query, err := request.RequireString("query")
@@ -140,9 +361,9 @@ func (s *GenshinStarcraftMCPServer) handleSearch(ctx context.Context, request mc
 
 	utils.Debug("Handling search", "query", query)
 
-	searchID, results, err := s.browser.Search(query)
+	searchID, results, err := s.browser.Search(ctx, query)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("搜索失败: %v", err)), nil
+		return toolError("搜索", err), nil
 	}
 
 	content := fmt.Sprintf("搜索ID: %s\n找到 %d 个搜索结果", searchID, len(results))
@@ -158,24 +379,57 @@ func (s *GenshinStarcraftMCPServer) handleSearch(ctx context.Context, request mc
 
 // handleGetNavigation 处理获取导航请求
 func (s *GenshinStarcraftMCPServer) handleGetNavigation(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	utils.Debug("Handling get_navigation")
+	category := request.GetString("category", "")
+	depth := request.GetInt("depth", 0)
 
-	items, err := s.browser.GetNavigation()
+	utils.Debug("Handling get_navigation", "category", category, "depth", depth)
+
+	items, err := s.browser.GetNavigation(ctx)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("获取导航失败: %v", err)), nil
+		return toolError("获取导航", err), nil
 	}
 
 	if len(items) == 0 {
-		return mcp.NewToolResultText("没有找到导航目录"), nil
+		return s.withSnapshotNote(ctx, mcp.NewToolResultText("没有找到导航目录")), nil
 	}
 
-	var navText string
-	navText = "导航目录:\n\n"
-	for i, item := range items {
-		navText += fmt.Sprintf("%d. [%s](%s)\n", i+1, item.Title, item.URL)
+	title := "导航目录"
+	if category != "" {
+		found := scraper.FindNavigationCategory(items, category)
+		if found == nil {
+			var names []string
+			for _, item := range items {
+				names = append(names, item.Title)
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("未找到分类 '%s'，顶级分类有：%s", category, strings.Join(names, "、"))), nil
+		}
+
+		title = strings.Join(append(slices.Clone(found.Path), found.Title), " / ")
+		if len(found.Children) > 0 {
+			items = found.Children
+		} else {
+			items = []models.NavigationItem{*found}
+		}
 	}
 
-	return mcp.NewToolResultText(navText), nil
+	var navText strings.Builder
+	navText.WriteString(title + ":\n\n")
+	writeNavigation(&navText, scraper.LimitNavigationDepth(items, depth), 0)
+
+	return s.withSnapshotNote(ctx, mcp.NewToolResultText(navText.String())), nil
+}
+
+// writeNavigation 以嵌套列表输出导航树，分类加粗，页面输出为 [标题](ID)
+func writeNavigation(content *strings.Builder, items []models.NavigationItem, level int) {
+	indent := strings.Repeat("  ", level)
+	for _, item := range items {
+		if item.URL != "" {
+			content.WriteString(fmt.Sprintf("%s- [%s](%s)\n", indent, item.Title, item.URL))
+		} else {
+			content.WriteString(fmt.Sprintf("%s- **%s**\n", indent, item.Title))
+		}
+		writeNavigation(content, item.Children, level+1)
+	}
 }
 
 // handleGetGuide 处理获取指南请求
@@ -185,18 +439,127 @@ func (s *GenshinStarcraftMCPServer) handleGetGuide(ctx context.Context, request
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	utils.Debug("Handling get_guide", "id", id)
+	format := request.GetString("format", guideFormatMarkdown)
+	if format != guideFormatMarkdown && format != guideFormatJSON {
+		return mcp.NewToolResultError(fmt.Sprintf("不支持的格式 '%s'，可选值：%s、%s", format, guideFormatMarkdown, guideFormatJSON)), nil
+	}
+
+	utils.Debug("Handling get_guide", "id", id, "format", format)
+
+	tutorial, err := s.browser.GetTutorial(ctx, id)
+	if err != nil {
+		return toolError("获取指南", err), nil
+	}
+
+	if format == guideFormatJSON {
+		data, err := json.MarshalIndent(tutorial, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("序列化指南失败: %v", err)), nil
+		}
+		return s.withSnapshotNote(ctx, mcp.NewToolResultText(string(data))), nil
+	}
+
+	fullURL := s.browser.TutorialURL(tutorial.URL)
 
-	tutorial, err := s.browser.GetTutorial(id)
+	// 正文已是Markdown，标题位于正文开头时不再重复添加
+	body := tutorial.Content
+	if !strings.HasPrefix(body, "# "+tutorial.Title+"\n") {
+		body = fmt.Sprintf("# %s\n\n%s", tutorial.Title, body)
+	}
+	content := fmt.Sprintf("%s\n\n[原文链接](%s)", body, fullURL)
+
+	return s.withSnapshotNote(ctx, mcp.NewToolResultText(content)), nil
+}
+
+// handleGetGuideOutline 处理获取指南目录请求
+func (s *GenshinStarcraftMCPServer) handleGetGuideOutline(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("id")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("获取指南失败: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	fullURL := fmt.Sprintf("https://act.mihoyo.com/ys/ugc/tutorial/detail/%s", tutorial.URL)
-	content := fmt.Sprintf("# %s\n\n%s\n\n[原文链接](%s)",
-		tutorial.Title, tutorial.Content, fullURL)
+	utils.Debug("Handling get_guide_outline", "id", id)
 
-	return mcp.NewToolResultText(content), nil
+	tutorial, err := s.browser.GetTutorial(ctx, id)
+	if err != nil {
+		return toolError("获取指南目录", err), nil
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("# %s\n\n", tutorial.Title))
+	if tutorial.Summary != "" {
+		content.WriteString(fmt.Sprintf("**摘要**: %s\n\n", tutorial.Summary))
+	}
+
+	if len(tutorial.Outline) == 0 {
+		content.WriteString("该教程没有章节标题，请使用 get_guide 获取全文\n\n")
+	} else {
+		content.WriteString("**目录**（括号内为章节ID，可传给 get_guide_section）:\n\n")
+		writeOutline(&content, tutorial.Outline, 0)
+		content.WriteString("\n")
+	}
+
+	content.WriteString(fmt.Sprintf("[原文链接](%s)", s.browser.TutorialURL(tutorial.URL)))
+	return s.withSnapshotNote(ctx, mcp.NewToolResultText(content.String())), nil
+}
+
+// handleGetGuideSection 处理获取指南章节请求
+func (s *GenshinStarcraftMCPServer) handleGetGuideSection(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	sectionQuery, err := request.RequireString("section")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	includeChildren := request.GetBool("include_children", false)
+
+	utils.Debug("Handling get_guide_section", "id", id, "section", sectionQuery, "include_children", includeChildren)
+
+	tutorial, section, err := s.browser.GetTutorialSection(ctx, id, sectionQuery)
+	if err != nil {
+		return toolError("获取指南章节", err), nil
+	}
+
+	var content strings.Builder
+	writeSection(&content, section, includeChildren)
+
+	// 未展开子章节时列出子章节目录，便于继续按需获取
+	if !includeChildren && len(section.Children) > 0 {
+		content.WriteString("**子章节**:\n\n")
+		writeOutline(&content, section.Children, 0)
+		content.WriteString("\n")
+	}
+
+	content.WriteString(fmt.Sprintf("来自《%s》 [原文链接](%s)", tutorial.Title, s.browser.TutorialURL(tutorial.URL)))
+	return s.withSnapshotNote(ctx, mcp.NewToolResultText(content.String())), nil
+}
+
+// writeOutline 以嵌套列表输出章节目录
+func writeOutline(content *strings.Builder, sections []models.Section, depth int) {
+	for _, section := range sections {
+		content.WriteString(fmt.Sprintf("%s- %s (`%s`)\n", strings.Repeat("  ", depth), section.Title, section.ID))
+		writeOutline(content, section.Children, depth+1)
+	}
+}
+
+// writeSection 输出章节标题和正文，includeChildren 为true时递归输出子章节
+func writeSection(content *strings.Builder, section *models.Section, includeChildren bool) {
+	level := min(max(section.Level, 1), 6)
+	content.WriteString(fmt.Sprintf("%s %s\n\n", strings.Repeat("#", level), section.Title))
+	if section.Content != "" {
+		content.WriteString(section.Content + "\n\n")
+	}
+
+	if !includeChildren {
+		return
+	}
+	for i := range section.Children {
+		writeSection(content, &section.Children[i], true)
+	}
 }
 
 // handleOpenSearchResult 处理打开搜索结果请求
@@ -208,9 +571,9 @@ func (s *GenshinStarcraftMCPServer) handleOpenSearchResult(ctx context.Context,
 
 	utils.Debug("Handling open_search_result", "title", title)
 
-	content, err := s.browser.OpenSearchResultByTitle(title)
+	content, err := s.browser.OpenSearchResultByTitle(ctx, title)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("打开搜索结果失败: %v", err)), nil
+		return toolError("打开搜索结果", err), nil
 	}
 
 	// 直接返回页面内容，不需要额外的格式化
@@ -219,6 +582,109 @@ func (s *GenshinStarcraftMCPServer) handleOpenSearchResult(ctx context.Context,
 	return mcp.NewToolResultText(responseContent), nil
 }
 
+// handleParserHealth 处理解析器健康检查请求
+func (s *GenshinStarcraftMCPServer) handleParserHealth(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	run := request.GetBool("run", false)
+
+	utils.Debug("Handling parser_health", "run", run)
+
+	report := s.browser.HealthReport()
+	if run {
+		var err error
+		report, err = s.browser.CheckParserHealth(ctx)
+		if err != nil {
+			return toolError("解析器健康检查", err), nil
+		}
+	}
+
+	return mcp.NewToolResultText(report.Markdown()), nil
+}
+
+// handleWhatsNew 处理更新日志请求
+func (s *GenshinStarcraftMCPServer) handleWhatsNew(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	value, err := request.RequireString("since")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	since, err := scraper.ParseSince(value)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	format := request.GetString("format", guideFormatMarkdown)
+	if format != guideFormatMarkdown && format != guideFormatJSON {
+		return mcp.NewToolResultError(fmt.Sprintf("不支持的格式 '%s'，可选值：%s、%s", format, guideFormatMarkdown, guideFormatJSON)), nil
+	}
+
+	utils.Debug("Handling whats_new", "since", since, "format", format)
+
+	changelog, err := scraper.ChangelogSince(s.snapshotDir, since)
+	if err != nil {
+		return toolError("获取更新日志", err), nil
+	}
+
+	if format == guideFormatJSON {
+		data, err := json.MarshalIndent(changelog, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("序列化更新日志失败: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+
+	return mcp.NewToolResultText(changelog.Markdown()), nil
+}
+
+// handleCache 处理缓存管理请求
+func (s *GenshinStarcraftMCPServer) handleCache(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	action := request.GetString("action", cacheActionList)
+	kind := request.GetString("kind", "")
+	key := strings.TrimSpace(request.GetString("key", ""))
+	format := request.GetString("format", guideFormatMarkdown)
+
+	utils.Debug("Handling cache", "action", action, "kind", kind, "key", key, "format", format)
+
+	switch action {
+	case cacheActionList:
+		report := s.browser.CacheReport()
+		switch format {
+		case guideFormatMarkdown:
+			return mcp.NewToolResultText(report.Markdown()), nil
+		case guideFormatJSON:
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("序列化缓存报告失败: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(data)), nil
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("不支持的格式 '%s'，可选值：%s、%s", format, guideFormatMarkdown, guideFormatJSON)), nil
+		}
+
+	case cacheActionInvalidate, cacheActionRefresh:
+		if kind == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("%s 操作需要指定 kind，可选值：%s、%s、%s", action, scraper.PageKindNavigation, scraper.PageKindTutorial, scraper.PageKindNodeGraph)), nil
+		}
+		if action == cacheActionRefresh {
+			if err := s.browser.RefreshCache(ctx, kind, key); err != nil {
+				return toolError("刷新缓存", err), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("已重新获取并缓存 %s %s", kind, key)), nil
+		}
+
+		removed, err := s.browser.InvalidateCache(kind, key)
+		if err != nil {
+			return toolError("删除缓存", err), nil
+		}
+		layers := make([]string, 0, len(removed))
+		for _, entry := range removed {
+			layers = append(layers, entry.Layer)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("已从 %s 缓存中删除 %s %s，下次请求时重新获取", strings.Join(layers, "、"), kind, key)), nil
+
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("不支持的操作 '%s'，可选值：%s、%s、%s", action, cacheActionList, cacheActionInvalidate, cacheActionRefresh)), nil
+	}
+}
+
 // handleGetNodeGraphs 处理获取节点图列表请求
 func (s *GenshinStarcraftMCPServer) handleGetNodeGraphs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	clientType, err := request.RequireString("client_type")
@@ -233,9 +699,9 @@ func (s *GenshinStarcraftMCPServer) handleGetNodeGraphs(ctx context.Context, req
 
 	utils.Debug("Handling get_node_graphs", "client_type", clientType, "node_type", nodeType)
 
-	nodeGraphs, err := s.browser.GetNodeGraphs(clientType, nodeType)
+	nodeGraphs, err := s.browser.GetNodeGraphs(ctx, clientType, nodeType)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("获取节点图列表失败: %v", err)), nil
+		return toolError("获取节点图列表", err), nil
 	}
 
 	// 调试：打印前几个节点的分类信息
@@ -289,7 +755,7 @@ func (s *GenshinStarcraftMCPServer) handleGetNodeGraphs(ctx context.Context, req
 		}
 	}
 
-	return mcp.NewToolResultText(content.String()), nil
+	return s.withSnapshotNote(ctx, mcp.NewToolResultText(content.String())), nil
 }
 
 // handleGetNodeGraphDetails 处理获取节点图详情请求
@@ -311,9 +777,9 @@ func (s *GenshinStarcraftMCPServer) handleGetNodeGraphDetails(ctx context.Contex
 
 	utils.Debug("Handling get_node_graph_details", "client_type", clientType, "node_type", nodeType, "node_name", nodeName)
 
-	details, err := s.browser.GetNodeGraphDetails(clientType, nodeType, nodeName)
+	details, err := s.browser.GetNodeGraphDetails(ctx, clientType, nodeType, nodeName)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("获取节点图详情失败: %v", err)), nil
+		return toolError("获取节点图详情", err), nil
 	}
 
 	// 格式化返回内容
@@ -347,5 +813,5 @@ func (s *GenshinStarcraftMCPServer) handleGetNodeGraphDetails(ctx context.Contex
 		content += fmt.Sprintf("**使用示例**:\n```%s```\n\n", details.Example)
 	}
 
-	return mcp.NewToolResultText(content), nil
+	return s.withSnapshotNote(ctx, mcp.NewToolResultText(content)), nil
 }
\ No newline at end of file
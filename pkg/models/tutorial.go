@@ -15,7 +15,7 @@ type Section struct {
 	ID       string    `json:"id"`
 	Title    string    `json:"title"`
 	Level    int       `json:"level"`
-	Content  string    `json:"content"`
+	Content  string    `json:"content,omitempty"`
 	Tables   []Table   `json:"tables,omitempty"`
 	Params   []Param   `json:"params,omitempty"`
 	Children []Section `json:"children,omitempty"`
@@ -56,10 +56,12 @@ type CachedPage struct {
 	Expires     time.Time  `json:"expires"`
 }
 
-// NavigationItem 导航项
+// NavigationItem 导航项，分类项没有URL，只包含子项
 type NavigationItem struct {
-	Title string `json:"title"`
-	URL   string `json:"url"`
+	Title    string           `json:"title"`
+	URL      string           `json:"url,omitempty"`
+	Path     []string         `json:"path,omitempty"`     // 所属的各级分类标题
+	Children []NavigationItem `json:"children,omitempty"` // 子分类和页面
 }
 
 // NodeGraphItem 节点图项
@@ -3,75 +3,222 @@ package scraper
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-rod/rod"
 	"genshin-starcraft-mcp/pkg/models"
+	"genshin-starcraft-mcp/pkg/utils"
 )
 
+// 站点地址相关常量
+const (
+	// DefaultBaseURL 官方教程站点地址
+	DefaultBaseURL = "https://act.mihoyo.com"
+
+	// 教程详情页路径
+	tutorialPath = "/ys/ugc/tutorial/detail/"
+
+	// 包含导航菜单和搜索框的教程页面ID
+	navigationPageID = "mh29wpicgvh0"
+
+	// DefaultMaxPages 默认同时打开的最大页面数
+	DefaultMaxPages = 4
+)
+
+// Options 浏览器配置
+type Options struct {
+	Fetcher   string        // 页面获取方式：rod 或 http，为空时使用rod
+	BaseURL   string        // 站点地址，为空时使用官方站点
+	RecordDir string        // 录制模式：将访问过的页面DOM保存到该目录
+	ReplayDir string        // 回放模式：从该目录读取页面，不访问网络
+	MaxPages  int           // rod模式下同时打开的最大页面数，超出时排队等待
+	Retry     *RetryPolicy  // 页面获取和元素等待的重试策略，为空时使用默认策略
+	Cache     *CachePolicy  // 缓存的有效期和后台刷新策略，为空时使用默认策略
+	Chrome    ChromeOptions // rod模式下Chromium的连接和启动配置
+
+	HealthInterval time.Duration // rod模式下浏览器健康检查间隔，<=0 时只在打开页面失败时重启
+	NodeTypes      NodeTypes     // 节点类型到页面ID的映射，覆盖内置映射和从导航目录发现的映射
+	CacheDir       string        // 磁盘缓存目录，为空时只使用内存缓存；录制、回放和离线模式下不使用
+
+	Offline         bool   // 离线模式：只从快照读取内容，不访问网络也不启动Chromium
+	SnapshotDir     string // 快照根目录
+	OfflineSnapshot string // 离线模式使用的快照版本名或目录，为空时使用最新的完整快照
+}
+
+// baseURL 站点地址，去掉末尾的斜杠，为空时使用官方站点
+func (opts Options) baseURL() string {
+	if baseURL := strings.TrimRight(opts.BaseURL, "/"); baseURL != "" {
+		return baseURL
+	}
+	return DefaultBaseURL
+}
+
+// cachePolicy 缓存策略，未指定时使用默认策略
+func (opts Options) cachePolicy() CachePolicy {
+	if opts.Cache != nil {
+		return *opts.Cache
+	}
+	return DefaultCachePolicy()
+}
+
+// cacheDir 磁盘缓存目录，录制和回放模式需要每次都经过页面获取器，离线模式只读取快照，都不使用磁盘缓存
+func (opts Options) cacheDir() string {
+	if opts.RecordDir != "" || opts.ReplayDir != "" || opts.Offline {
+		return ""
+	}
+	return opts.CacheDir
+}
+
 // Browser 浏览器实例
 type Browser struct {
-	browser        *rod.Browser
-	nodeGraphCache map[string]*models.NodeGraphPage // 缓存完整的页面解析结构，key为clientType_nodeType
+	fetcher  Fetcher
+	baseURL  string
+	rod      *RodFetcher  // 仅rod模式下可用，用于搜索等需要页面交互的操作
+	offline  *Snapshot    // 仅离线模式下可用，所有内容都从该快照读取
+	fallback *Snapshot    // 内嵌数据集，实时获取失败时使用，未内嵌时为nil
+	retry    RetryPolicy  // 页面获取的重试策略
+	memory   *memoryCache // 内存缓存：导航目录、教程和节点类型页面的解析结果，按大小限制淘汰
+	disk     *diskCache   // 磁盘缓存，进程重启后仍可使用，未启用时为nil
+	cache    CachePolicy  // 缓存的有效期和后台刷新策略
+
+	navigationFlight flightGroup[[]models.NavigationItem] // 合并并发的导航目录获取
+	tutorialFlight   flightGroup[*models.Tutorial]        // 合并同一教程ID的并发获取，key为教程ID
+	nodeGraphFlight  flightGroup[*models.NodeGraphPage]   // 合并同一节点类型页面的并发获取，key为clientType_nodeType
+
+	refreshMu  sync.Mutex
+	refreshing map[string]bool // 正在后台刷新的缓存条目，key为 种类/缓存key
+
+	accessMu sync.Mutex
+	accessed map[cacheRef]time.Time // 缓存条目最近一次被读取的时间，定期刷新只刷新上一轮之后读取过的条目
+
+	ctx        context.Context // 后台任务的生命周期，Close 时取消
+	cancel     context.CancelFunc
+	background sync.WaitGroup // 后台刷新任务
+
+	nodeTypesMu         sync.RWMutex
+	nodeTypeOverrides   NodeTypes // 配置中指定的节点类型映射
+	discoveredNodeTypes NodeTypes // 从导航目录发现的节点类型映射
+	nodeTypesCheckedAt  time.Time // 最近一次发现的时间
+	nodeTypesNextCheck  time.Time // 下次需要重新发现的时间
+
+	health healthRecorder // 各页面解析结果的检查记录
+	stats  cacheCounters  // 缓存命中计数
 }
 
 // NewBrowser 创建新的浏览器实例
-func NewBrowser() (*Browser, error) {
-	browser := rod.New().
-		MustConnect().
-		MustIgnoreCertErrors(true)
-
-	return &Browser{
-		browser:        browser,
-		nodeGraphCache: make(map[string]*models.NodeGraphPage),
-	}, nil
+func NewBrowser(opts Options) (*Browser, error) {
+	// 无法创建获取器（如本机没有Chromium）时，有内嵌数据集则只从数据集读取
+	fallback := opts.fallbackSnapshot()
+	fetcher, err := newFetcher(opts)
+	if err != nil {
+		if fallback == nil {
+			return nil, err
+		}
+		utils.Error("Failed to create fetcher, serving embedded dataset", "version", fallback.Manifest.Version, "error", err)
+		fetcher = &offlineFetcher{snapshot: fallback}
+	}
+
+	baseURL := opts.baseURL()
+
+	retry := DefaultRetryPolicy()
+	if opts.Retry != nil {
+		retry = *opts.Retry
+	}
+
+	cache := opts.cachePolicy()
+	cacheDir := opts.cacheDir()
+
+	b := &Browser{
+		fetcher:    fetcher,
+		baseURL:    baseURL,
+		rod:        rodFetcherOf(fetcher),
+		offline:    offlineSnapshotOf(fetcher),
+		fallback:   fallback,
+		retry:      retry,
+		memory:     newMemoryCache(cache.MaxMemory),
+		disk:       newDiskCache(cacheDir, baseURL),
+		cache:      cache,
+		refreshing: make(map[string]bool),
+		accessed:   make(map[cacheRef]time.Time),
+
+		nodeTypeOverrides: opts.NodeTypes.clone(),
+	}
+
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	if cache.RefreshInterval > 0 && b.offline == nil {
+		b.background.Add(1)
+		go b.refreshLoop(cache.RefreshInterval)
+	}
+
+	utils.Debug("Browser created", "fetcher", fmt.Sprintf("%T", fetcher), "base_url", baseURL, "cache_dir", cacheDir)
+	return b, nil
+}
+
+// TutorialURL 根据页面ID拼接完整的教程页面URL
+func (b *Browser) TutorialURL(id string) string {
+	return b.baseURL + tutorialPath + id
 }
 
-// Close 关闭浏览器
+// Close 停止后台刷新并关闭浏览器
 func (b *Browser) Close() error {
-	if b.browser != nil {
-		return b.browser.Close()
+	b.refreshMu.Lock()
+	b.cancel()
+	b.refreshMu.Unlock()
+	b.background.Wait()
+
+	if b.fetcher != nil {
+		return b.fetcher.Close()
 	}
 	return nil
 }
 
-// NewPage 创建新页面
-func (b *Browser) NewPage(url string) (*rod.Page, error) {
-	page := b.browser.MustPage()
-
-	// 导航到URL
-	err := page.Navigate(url)
-	if err != nil {
-		page.Close()
-		return nil, fmt.Errorf("failed to navigate to %s: %w", url, err)
+// NewPage 创建绑定到ctx的新页面，仅rod模式可用，使用完毕后需调用 ClosePage
+func (b *Browser) NewPage(ctx context.Context, url string) (*rod.Page, error) {
+	if b.rod == nil {
+		return nil, fmt.Errorf("%w: page interaction requires the %s fetcher", ErrBrowserUnavailable, FetcherRod)
 	}
 
-	// 等待网络空闲
-	page.MustWaitIdle()
+	var page *rod.Page
+	err := b.retry.Do(ctx, "open "+url, func() error {
+		var err error
+		page, err = b.rod.NewPage(ctx, url)
+		return err
+	})
+	return page, err
+}
+
+// ClosePage 关闭页面并归还页面池名额
+func (b *Browser) ClosePage(page *rod.Page) {
+	if b.rod != nil {
+		b.rod.ClosePage(page)
+	}
+}
 
-	return page, nil
+// BrowserStatus 返回Chromium的运行状态，非rod模式返回nil
+func (b *Browser) BrowserStatus() *BrowserStatus {
+	if b.rod == nil {
+		return nil
+	}
+	status := b.rod.Status()
+	return &status
 }
 
-// WaitForElement 等待元素出现
+// WaitForElement 等待元素出现，超时基于页面绑定的ctx
 func (b *Browser) WaitForElement(page *rod.Page, selector string, timeout time.Duration) (*rod.Element, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	element, err := page.Context(ctx).Element(selector)
+	element, err := page.Timeout(timeout).Element(selector)
 	if err != nil {
 		return nil, fmt.Errorf("element %s not found: %w", selector, err)
 	}
 	return element, nil
 }
 
-// WaitForDialog 等待对话框出现
+// WaitForDialog 等待对话框出现，超时基于页面绑定的ctx
 func (b *Browser) WaitForDialog(page *rod.Page, timeout time.Duration) (*rod.Element, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	element, err := page.Context(ctx).Element("div[role=dialog]")
+	element, err := page.Timeout(timeout).Element("div[role=dialog]")
 	if err != nil {
 		return nil, fmt.Errorf("dialog not found: %w", err)
 	}
 	return element, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestBrowserConcurrentTools 并发调用各个工具使用的方法，配合 go test -race 检查共享状态的数据竞争
+func TestBrowserConcurrentTools(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxMemory int64
+	}{
+		{name: "cached", maxMemory: DefaultMemoryCacheSize},
+		// 上限小于任何条目时不缓存，每次调用都会获取页面
+		{name: "uncached", maxMemory: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := newFakeSite(t)
+			site.delay = 5 * time.Millisecond
+
+			cache := DefaultCachePolicy()
+			cache.RefreshInterval = 0
+			cache.MaxMemory = tt.maxMemory
+			b := newTestBrowser(t, site, Options{Cache: &cache})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			const workers = 16
+			var wg sync.WaitGroup
+			errs := make(chan error, workers*6)
+			for i := 0; i < workers; i++ {
+				wg.Add(6)
+				go func() {
+					defer wg.Done()
+					graphs, err := b.GetNodeGraphs(ctx, "服务器节点", "执行节点")
+					if err == nil && len(graphs) != 3 {
+						err = fmt.Errorf("GetNodeGraphs returned %d nodes, want 3", len(graphs))
+					}
+					errs <- err
+				}()
+				go func() {
+					defer wg.Done()
+					details, err := b.GetNodeGraphDetails(ctx, "服务器节点", "查询节点", "获取自定义变量")
+					if err == nil && (len(details.Inputs) != 1 || len(details.Outputs) != 1) {
+						err = fmt.Errorf("GetNodeGraphDetails returned %d inputs and %d outputs, want 1 and 1", len(details.Inputs), len(details.Outputs))
+					}
+					errs <- err
+				}()
+				go func() {
+					defer wg.Done()
+					_, err := b.GetNodeGraphDetails(ctx, "客户端节点", "执行节点", "播放特效")
+					errs <- err
+				}()
+				go func() {
+					defer wg.Done()
+					tutorial, err := b.GetTutorial(ctx, fakeGuideID)
+					if err == nil && tutorial.Title != "节点图基础" {
+						err = fmt.Errorf("GetTutorial returned title %q", tutorial.Title)
+					}
+					errs <- err
+				}()
+				go func() {
+					defer wg.Done()
+					_, err := b.GetNavigation(ctx)
+					errs <- err
+				}()
+				go func() {
+					defer wg.Done()
+					// 管理和诊断接口与获取并发读取缓存和检查记录
+					b.CacheReport()
+					b.HealthReport()
+					b.KnownNodeTypes()
+					errs <- nil
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
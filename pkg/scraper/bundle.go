@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// EmbeddedDatasetFile 内嵌数据集相对仓库根目录的路径，使用 embeddata 构建标签时编译进可执行文件
+const EmbeddedDatasetFile = "pkg/scraper/embedded/dataset.json.gz"
+
+// snapshotBundle 快照清单和所有成功获取的页面文件，以gzip压缩的JSON保存为单个文件
+type snapshotBundle struct {
+	Manifest *SnapshotManifest          `json:"manifest"`
+	Files    map[string]json.RawMessage `json:"files"` // 按相对快照目录的文件路径索引
+}
+
+// WriteSnapshotBundle 将快照打包为gzip压缩的数据集，获取失败的页面不包含在内
+func WriteSnapshotBundle(w io.Writer, snapshot *Snapshot) error {
+	bundle := snapshotBundle{
+		Manifest: snapshot.Manifest,
+		Files:    make(map[string]json.RawMessage),
+	}
+	for _, entry := range snapshot.Manifest.Entries {
+		if entry.Error != "" {
+			continue
+		}
+		data, err := snapshot.readFile(entry.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot file: %w", err)
+		}
+		bundle.Files[entry.Path] = data
+	}
+
+	zw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(zw).Encode(bundle); err != nil {
+		return fmt.Errorf("failed to encode dataset: %w", err)
+	}
+	return zw.Close()
+}
+
+// ReadSnapshotBundle 读取 WriteSnapshotBundle 生成的数据集，文件内容保存在内存中
+func ReadSnapshotBundle(r io.Reader) (*Snapshot, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer zr.Close()
+
+	var bundle snapshotBundle
+	if err := json.NewDecoder(zr).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset: %w", err)
+	}
+	if bundle.Manifest == nil {
+		return nil, fmt.Errorf("dataset has no manifest")
+	}
+	if bundle.Manifest.SchemaVersion != SnapshotSchemaVersion {
+		return nil, fmt.Errorf("dataset has schema version %d, expected %d", bundle.Manifest.SchemaVersion, SnapshotSchemaVersion)
+	}
+
+	files := make(map[string][]byte, len(bundle.Files))
+	for path, data := range bundle.Files {
+		files[path] = data
+	}
+	return &Snapshot{Manifest: bundle.Manifest, files: files}, nil
+}
+
+// 内嵌数据集只在第一次使用时解压
+var (
+	embeddedOnce     sync.Once
+	embeddedSnapshot *Snapshot
+)
+
+// EmbeddedSnapshot 返回构建时内嵌的数据集，未使用 embeddata 标签构建或数据集无法读取时返回nil
+func EmbeddedSnapshot() *Snapshot {
+	embeddedOnce.Do(func() {
+		if len(embeddedDataset) == 0 {
+			return
+		}
+		snapshot, err := ReadSnapshotBundle(bytes.NewReader(embeddedDataset))
+		if err != nil {
+			utils.Error("Failed to read embedded dataset", "error", err)
+			return
+		}
+		snapshot.Dir = "embedded"
+		embeddedSnapshot = snapshot
+		utils.Debug("Embedded dataset loaded", "version", snapshot.Manifest.Version, "files", len(snapshot.files))
+	})
+	return embeddedSnapshot
+}
@@ -0,0 +1,273 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// 后台刷新单个缓存条目的超时时间
+const cacheRefreshTimeout = 3 * time.Minute
+
+// CachePolicy 缓存策略：各类内容的有效期、过期后仍可使用的时长和后台刷新间隔
+type CachePolicy struct {
+	NavigationTTL time.Duration // 导航目录的有效期
+	TutorialTTL   time.Duration // 教程的有效期
+	NodeGraphTTL  time.Duration // 节点类型页面的有效期
+
+	// MaxStale 过期后仍直接返回旧数据（同时在后台刷新）的时长，超过后同步重新获取；0 表示过期后总是同步获取
+	MaxStale time.Duration
+
+	// RefreshInterval 后台定期刷新的间隔，每次刷新在下个间隔内将过期、且上次刷新后被读取过的缓存，<=0 表示关闭
+	RefreshInterval time.Duration
+
+	// MaxMemory 内存缓存的大小上限（字节，按JSON编码后的大小估算），超出时淘汰最久未使用的条目，<=0 表示不限制
+	MaxMemory int64
+}
+
+// DefaultCachePolicy 返回默认缓存策略
+func DefaultCachePolicy() CachePolicy {
+	return CachePolicy{
+		NavigationTTL:   6 * time.Hour,
+		TutorialTTL:     24 * time.Hour,
+		NodeGraphTTL:    24 * time.Hour,
+		MaxStale:        7 * 24 * time.Hour,
+		RefreshInterval: time.Hour,
+		MaxMemory:       DefaultMemoryCacheSize,
+	}
+}
+
+// cacheState 缓存内容的新鲜程度
+type cacheState int
+
+const (
+	cacheFresh   cacheState = iota // 未过期，直接使用
+	cacheStale                     // 已过期但仍可使用，同时在后台刷新
+	cacheExpired                   // 过期太久，需要同步重新获取
+)
+
+// String 返回新鲜程度的名称
+func (s cacheState) String() string {
+	switch s {
+	case cacheFresh:
+		return "fresh"
+	case cacheStale:
+		return "stale"
+	default:
+		return "expired"
+	}
+}
+
+// freshness 根据过期时间判断缓存内容的新鲜程度
+func (p CachePolicy) freshness(expires time.Time) cacheState {
+	now := time.Now()
+	switch {
+	case now.Before(expires):
+		return cacheFresh
+	case now.Before(expires.Add(p.MaxStale)):
+		return cacheStale
+	default:
+		return cacheExpired
+	}
+}
+
+// serveCached 根据新鲜程度决定是否使用缓存：过期但仍可使用时在后台刷新并返回true。
+// 同时记录条目的读取时间，定期刷新只刷新近期被读取过的条目
+func (b *Browser) serveCached(kind string, key string, expires time.Time) bool {
+	b.recordAccess(kind, key)
+
+	switch b.cache.freshness(expires) {
+	case cacheFresh:
+		return true
+	case cacheStale:
+		utils.Debug("Serving stale cache", "kind", kind, "key", key, "expires", expires)
+		b.stats.staleHits.Add(1)
+		b.revalidate(kind, key)
+		return true
+	default:
+		return false
+	}
+}
+
+// revalidate 在后台刷新缓存条目，同一条目同时只有一个刷新任务
+func (b *Browser) revalidate(kind string, key string) {
+	b.refreshMu.Lock()
+	defer b.refreshMu.Unlock()
+
+	// 在锁内检查并登记，保证 Close 等待时不会再有新的后台任务
+	name := kind + "/" + key
+	if b.ctx.Err() != nil || b.refreshing[name] {
+		return
+	}
+	b.refreshing[name] = true
+
+	b.background.Add(1)
+	go func() {
+		defer b.background.Done()
+		defer b.finishRefresh(kind, key)
+		b.refreshEntry(kind, key)
+	}()
+}
+
+// startRefresh 登记正在刷新的条目，已在刷新时返回false
+func (b *Browser) startRefresh(kind string, key string) bool {
+	b.refreshMu.Lock()
+	defer b.refreshMu.Unlock()
+
+	name := kind + "/" + key
+	if b.refreshing[name] {
+		return false
+	}
+	b.refreshing[name] = true
+	return true
+}
+
+// finishRefresh 取消条目的刷新登记
+func (b *Browser) finishRefresh(kind string, key string) {
+	b.refreshMu.Lock()
+	defer b.refreshMu.Unlock()
+
+	delete(b.refreshing, kind+"/"+key)
+}
+
+// refreshEntry 在后台重新获取缓存条目对应的页面，失败只记录日志
+func (b *Browser) refreshEntry(kind string, key string) {
+	ctx, cancel := context.WithTimeout(b.ctx, cacheRefreshTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := b.fetchEntry(ctx, kind, key); err != nil {
+		if !errors.Is(err, context.Canceled) {
+			utils.Error("Background cache refresh failed", "kind", kind, "key", key, "error", err)
+		}
+		return
+	}
+	utils.Debug("Background cache refresh completed", "kind", kind, "key", key, "elapsed", time.Since(start))
+}
+
+// fetchEntry 重新获取缓存条目对应的页面，结果由获取函数写入缓存
+func (b *Browser) fetchEntry(ctx context.Context, kind string, key string) error {
+	var err error
+	switch kind {
+	case PageKindNavigation:
+		_, err = b.fetchNavigation(ctx)
+	case PageKindTutorial:
+		_, err = b.fetchTutorial(ctx, key)
+	case PageKindNodeGraph:
+		clientType, nodeType, _ := strings.Cut(key, "_")
+		if graphID := b.getNodeGraphID(ctx, clientType, nodeType); graphID != "" {
+			_, err = b.loadNodeGraphPage(ctx, clientType, nodeType, graphID)
+		} else {
+			err = fmt.Errorf("%w '%s' for client_type '%s'", ErrUnknownNodeType, nodeType, clientType)
+		}
+	default:
+		err = fmt.Errorf("unknown cache kind %s", kind)
+	}
+
+	b.stats.refreshes.Add(1)
+	if err != nil {
+		b.stats.refreshFailures.Add(1)
+	}
+	return err
+}
+
+// refreshLoop 定期刷新即将过期、且上一轮刷新之后被读取过的缓存条目
+func (b *Browser) refreshLoop(interval time.Duration) {
+	defer b.background.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	since := time.Now()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+		b.refreshDue(now.Add(interval), since)
+		since = now
+	}
+}
+
+// refreshDue 逐个刷新在 before 之前过期、且在 since 之后被读取过的缓存条目。
+// 之后没有再被读取的条目不再刷新，过期后由下次读取重新获取
+func (b *Browser) refreshDue(before time.Time, since time.Time) {
+	due := b.dueEntries(before, since)
+	if len(due) == 0 {
+		return
+	}
+
+	utils.Info("Refreshing expiring cache entries", "count", len(due))
+	for _, entry := range due {
+		if b.ctx.Err() != nil {
+			return
+		}
+		if !b.startRefresh(entry.kind, entry.key) {
+			continue
+		}
+		b.refreshEntry(entry.kind, entry.key)
+		b.finishRefresh(entry.kind, entry.key)
+	}
+}
+
+// cacheRef 缓存条目的种类和key
+type cacheRef struct {
+	kind string
+	key  string
+}
+
+// dueEntries 收集内存和磁盘缓存中在 before 之前过期、但仍可使用，且在 since 之后被读取过的条目
+func (b *Browser) dueEntries(before time.Time, since time.Time) []cacheRef {
+	accessed := b.accessedSince(since)
+
+	var due []cacheRef
+	seen := map[cacheRef]bool{}
+	add := func(kind string, key string, expires time.Time) {
+		ref := cacheRef{kind: kind, key: key}
+		if seen[ref] || !accessed[ref] || !expires.Before(before) || b.cache.freshness(expires) == cacheExpired {
+			return
+		}
+		seen[ref] = true
+		due = append(due, ref)
+	}
+
+	for _, entry := range b.memory.list() {
+		add(entry.kind, entry.key, entry.expires)
+	}
+
+	for _, file := range b.disk.list() {
+		add(file.Kind, file.Key, file.Expires)
+	}
+
+	return due
+}
+
+// recordAccess 记录缓存条目被读取的时间
+func (b *Browser) recordAccess(kind string, key string) {
+	b.accessMu.Lock()
+	defer b.accessMu.Unlock()
+
+	b.accessed[cacheRef{kind: kind, key: key}] = time.Now()
+}
+
+// accessedSince 返回在 since 之后被读取过的条目，同时清除更早的读取记录
+func (b *Browser) accessedSince(since time.Time) map[cacheRef]bool {
+	b.accessMu.Lock()
+	defer b.accessMu.Unlock()
+
+	accessed := make(map[cacheRef]bool)
+	for ref, at := range b.accessed {
+		if at.Before(since) {
+			delete(b.accessed, ref)
+			continue
+		}
+		accessed[ref] = true
+	}
+	return accessed
+}
@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRefreshDueOnlyAccessedEntries 定期刷新只刷新上一轮之后被读取过的条目
+func TestRefreshDueOnlyAccessedEntries(t *testing.T) {
+	site := newFakeSite(t)
+	b := newTestBrowser(t, site, Options{CacheDir: t.TempDir()})
+	ctx := context.Background()
+
+	if _, err := b.GetNavigation(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.GetTutorial(ctx, fakeGuideID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 上一轮刷新之后只读取了教程
+	since := time.Now()
+	if _, err := b.GetTutorial(ctx, fakeGuideID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 所有条目都会在 before 之前过期
+	before := time.Now().Add(48 * time.Hour)
+	b.refreshDue(before, since)
+	if hits := site.hitCount(fakeGuideID); hits != 2 {
+		t.Errorf("tutorial fetched %d times, want 2 (refreshed once)", hits)
+	}
+	if hits := site.hitCount(navigationPageID); hits != 1 {
+		t.Errorf("navigation fetched %d times, want 1 (not read since last refresh)", hits)
+	}
+
+	// 之后没有再读取，下一轮不再刷新
+	b.refreshDue(before, time.Now())
+	if hits := site.hitCount(fakeGuideID); hits != 2 {
+		t.Errorf("tutorial fetched %d times after an idle round, want 2", hits)
+	}
+}
+
+// TestCrawlDoesNotFillCache 抓取的页面只写入快照，不进入内存和磁盘缓存
+func TestCrawlDoesNotFillCache(t *testing.T) {
+	site := newFakeSite(t)
+	b := newTestBrowser(t, site, Options{CacheDir: t.TempDir()})
+
+	manifest, err := b.Crawl(context.Background(), CrawlOptions{OutputDir: t.TempDir(), Interval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内置映射中的节点类型页面在假站点中不存在，只检查假站点中的页面
+	snapshot := &Snapshot{Manifest: manifest}
+	for _, path := range []string{snapshotNavigationFile, snapshotGuidePath(fakeGuideID), snapshotNodePath(fakeServerExecID)} {
+		if entry, ok := snapshot.Entry(path); !ok || entry.Error != "" {
+			t.Fatalf("page %s was not crawled: %+v", path, entry)
+		}
+	}
+
+	if entries := b.CacheReport().Entries; len(entries) != 0 {
+		t.Errorf("crawl left %d cache entries: %+v", len(entries), entries)
+	}
+}
+
+// TestDiskHitKeepsStoredAt 从磁盘缓存读入内存时沿用磁盘条目的写入时间，不按当前的有效期倒推
+func TestDiskHitKeepsStoredAt(t *testing.T) {
+	site := newFakeSite(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	first := newTestBrowser(t, site, Options{CacheDir: dir})
+	if _, err := first.GetNavigation(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 有效期变化后，由过期时间倒推的写入时间会偏离实际写入时间
+	cache := DefaultCachePolicy()
+	cache.RefreshInterval = 0
+	cache.NavigationTTL = time.Hour
+	second := newTestBrowser(t, site, Options{CacheDir: dir, Cache: &cache})
+	if _, err := second.GetNavigation(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if hits := site.hitCount(navigationPageID); hits != 1 {
+		t.Fatalf("navigation fetched %d times, want 1 (served from disk)", hits)
+	}
+
+	storedAt := map[string]time.Time{}
+	for _, entry := range second.CacheReport().Entries {
+		if entry.Kind == PageKindNavigation {
+			storedAt[entry.Layer] = entry.StoredAt
+		}
+	}
+	disk, memory := storedAt[CacheLayerDisk], storedAt[CacheLayerMemory]
+	if disk.IsZero() || !memory.Equal(disk) {
+		t.Errorf("memory entry stored at %s, disk entry stored at %s", memory, disk)
+	}
+}
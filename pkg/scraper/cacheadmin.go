@@ -0,0 +1,264 @@
+package scraper
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// 缓存层
+const (
+	CacheLayerMemory = "memory"
+	CacheLayerDisk   = "disk"
+)
+
+// CacheEntry 缓存条目
+type CacheEntry struct {
+	Kind     string    `json:"kind"`  // 页面种类：navigation、tutorial、node_graph
+	Key      string    `json:"key"`   // 导航目录为 navigation，教程为教程ID，节点类型页面为 clientType_nodeType
+	Layer    string    `json:"layer"` // 所在缓存层：memory 或 disk
+	StoredAt time.Time `json:"stored_at"`
+	Expires  time.Time `json:"expires"`
+	State    string    `json:"state"` // fresh、stale（过期但仍可使用）或 expired
+	Size     int64     `json:"size"`  // 字节数，内存缓存按JSON编码后的大小估算
+}
+
+// Age 条目已缓存的时长
+func (e CacheEntry) Age() time.Duration {
+	return time.Since(e.StoredAt)
+}
+
+// CacheStats 进程启动以来的缓存命中计数
+type CacheStats struct {
+	MemoryHits      int64 `json:"memory_hits"`
+	DiskHits        int64 `json:"disk_hits"`
+	StaleHits       int64 `json:"stale_hits"` // 命中中返回过期数据并在后台刷新的次数
+	Misses          int64 `json:"misses"`
+	Refreshes       int64 `json:"refreshes"` // 后台刷新和手动刷新的次数
+	RefreshFailures int64 `json:"refresh_failures"`
+	MemoryBytes     int64 `json:"memory_bytes"` // 内存缓存当前占用的字节数
+	MemoryLimit     int64 `json:"memory_limit"` // 内存缓存的大小上限，0 表示不限制
+	Evictions       int64 `json:"evictions"`    // 因超出大小上限被淘汰的条目数
+}
+
+// HitRate 命中率，没有请求时返回0
+func (s CacheStats) HitRate() float64 {
+	total := s.MemoryHits + s.DiskHits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.MemoryHits+s.DiskHits) / float64(total)
+}
+
+// cacheCounters 缓存命中计数器
+type cacheCounters struct {
+	memoryHits      atomic.Int64
+	diskHits        atomic.Int64
+	staleHits       atomic.Int64
+	misses          atomic.Int64
+	refreshes       atomic.Int64
+	refreshFailures atomic.Int64
+}
+
+// snapshot 读取当前计数
+func (c *cacheCounters) snapshot() CacheStats {
+	return CacheStats{
+		MemoryHits:      c.memoryHits.Load(),
+		DiskHits:        c.diskHits.Load(),
+		StaleHits:       c.staleHits.Load(),
+		Misses:          c.misses.Load(),
+		Refreshes:       c.refreshes.Load(),
+		RefreshFailures: c.refreshFailures.Load(),
+	}
+}
+
+// CacheReport 缓存内容和命中计数
+type CacheReport struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	DiskEnabled bool         `json:"disk_enabled"`
+	Entries     []CacheEntry `json:"entries"`
+	Stats       *CacheStats  `json:"stats,omitempty"` // 只在服务器进程内统计，命令行中为空
+}
+
+// Markdown 将缓存报告格式化为Markdown
+func (r *CacheReport) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# 缓存\n\n")
+
+	if r.Stats != nil {
+		s := r.Stats
+		sb.WriteString(fmt.Sprintf("命中: 内存 %d，磁盘 %d（其中过期数据 %d），未命中 %d，命中率 %.1f%%\n", s.MemoryHits, s.DiskHits, s.StaleHits, s.Misses, s.HitRate()*100))
+		sb.WriteString(fmt.Sprintf("刷新: %d 次，失败 %d 次\n", s.Refreshes, s.RefreshFailures))
+		limit := "不限制"
+		if s.MemoryLimit > 0 {
+			limit = formatSize(s.MemoryLimit)
+		}
+		sb.WriteString(fmt.Sprintf("内存: %s / %s，已淘汰 %d 个条目\n\n", formatSize(s.MemoryBytes), limit, s.Evictions))
+	}
+	if !r.DiskEnabled {
+		sb.WriteString("磁盘缓存未启用\n\n")
+	}
+
+	if len(r.Entries) == 0 {
+		sb.WriteString("缓存为空\n")
+		return sb.String()
+	}
+
+	sb.WriteString("| 种类 | key | 缓存层 | 已缓存 | 过期时间 | 状态 | 大小 |\n")
+	sb.WriteString("|------|-----|--------|--------|----------|------|------|\n")
+	for _, entry := range r.Entries {
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %s |\n",
+			entry.Kind, entry.Key, entry.Layer, entry.Age().Round(time.Second), entry.Expires.Format(time.DateTime), entry.State, formatSize(entry.Size)))
+	}
+	return sb.String()
+}
+
+// formatSize 将字节数格式化为便于阅读的大小
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// ParseCacheRef 解析 种类:key 形式的缓存条目，如 tutorial:mh29wpicgvh0、node_graph:服务器节点_执行节点；
+// 导航目录只有一份，可以只写 navigation
+func ParseCacheRef(ref string) (string, string, error) {
+	kind, key, _ := strings.Cut(strings.TrimSpace(ref), ":")
+	return checkCacheRef(kind, key)
+}
+
+// checkCacheRef 检查缓存条目的种类和key，导航目录的key为空时使用唯一的导航目录key
+func checkCacheRef(kind string, key string) (string, string, error) {
+	key = strings.TrimSpace(key)
+	switch kind {
+	case PageKindNavigation:
+		if key == "" {
+			key = navigationCacheKey
+		}
+	case PageKindTutorial, PageKindNodeGraph:
+		if key == "" {
+			return "", "", fmt.Errorf("%w: missing key for kind %s", ErrInvalidCacheKey, kind)
+		}
+	default:
+		return "", "", fmt.Errorf("%w: unknown kind '%s', expected %s, %s or %s", ErrInvalidCacheKey, kind, PageKindNavigation, PageKindTutorial, PageKindNodeGraph)
+	}
+	return kind, key, nil
+}
+
+// CacheReport 返回内存和磁盘缓存中的条目以及命中计数
+func (b *Browser) CacheReport() *CacheReport {
+	var entries []CacheEntry
+
+	for _, entry := range b.memory.list() {
+		entries = append(entries, CacheEntry{
+			Kind:     entry.kind,
+			Key:      entry.key,
+			Layer:    CacheLayerMemory,
+			StoredAt: entry.storedAt,
+			Expires:  entry.expires,
+			State:    b.cache.freshness(entry.expires).String(),
+			Size:     entry.size,
+		})
+	}
+
+	report := newCacheReport(b.disk, b.cache, entries)
+	stats := b.stats.snapshot()
+	stats.MemoryBytes, stats.Evictions = b.memory.usage()
+	stats.MemoryLimit = max(b.cache.MaxMemory, 0)
+	report.Stats = &stats
+	return report
+}
+
+// InvalidateCache 从内存和磁盘缓存中删除指定种类和key的条目，返回删除的条目
+func (b *Browser) InvalidateCache(kind string, key string) ([]CacheEntry, error) {
+	kind, key, err := checkCacheRef(kind, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []CacheEntry
+	if b.memory.remove(kind, key) {
+		removed = append(removed, CacheEntry{Kind: kind, Key: key, Layer: CacheLayerMemory})
+	}
+	if b.disk.remove(kind, key) {
+		removed = append(removed, CacheEntry{Kind: kind, Key: key, Layer: CacheLayerDisk})
+	}
+
+	if len(removed) == 0 {
+		return nil, fmt.Errorf("%w: %s:%s", ErrCacheEntryNotFound, kind, key)
+	}
+	utils.Info("Cache entry invalidated", "kind", kind, "key", key, "layers", len(removed))
+	return removed, nil
+}
+
+// RefreshCache 立即重新获取指定种类和key的页面并更新缓存
+func (b *Browser) RefreshCache(ctx context.Context, kind string, key string) error {
+	kind, key, err := checkCacheRef(kind, key)
+	if err != nil {
+		return err
+	}
+	if !b.startRefresh(kind, key) {
+		return fmt.Errorf("cache entry %s:%s is already refreshing", kind, key)
+	}
+	defer b.finishRefresh(kind, key)
+
+	start := time.Now()
+	if err := b.fetchEntry(ctx, kind, key); err != nil {
+		return err
+	}
+	utils.Info("Cache entry refreshed", "kind", kind, "key", key, "elapsed", time.Since(start))
+	return nil
+}
+
+// DiskCacheReport 返回磁盘缓存中的条目，不创建浏览器，用于在服务器进程外查看缓存
+func DiskCacheReport(opts Options) *CacheReport {
+	return newCacheReport(newDiskCache(opts.cacheDir(), opts.baseURL()), opts.cachePolicy(), nil)
+}
+
+// InvalidateDiskCache 删除磁盘缓存中指定种类和key的条目，不创建浏览器
+func InvalidateDiskCache(opts Options, kind string, key string) ([]CacheEntry, error) {
+	kind, key, err := checkCacheRef(kind, key)
+	if err != nil {
+		return nil, err
+	}
+	if !newDiskCache(opts.cacheDir(), opts.baseURL()).remove(kind, key) {
+		return nil, fmt.Errorf("%w: %s:%s", ErrCacheEntryNotFound, kind, key)
+	}
+	return []CacheEntry{{Kind: kind, Key: key, Layer: CacheLayerDisk}}, nil
+}
+
+// newCacheReport 合并内存条目和磁盘缓存中的条目，按种类、key和缓存层排序
+func newCacheReport(disk *diskCache, policy CachePolicy, entries []CacheEntry) *CacheReport {
+	for _, file := range disk.list() {
+		entries = append(entries, CacheEntry{
+			Kind:     file.Kind,
+			Key:      file.Key,
+			Layer:    CacheLayerDisk,
+			StoredAt: file.StoredAt,
+			Expires:  file.Expires,
+			State:    policy.freshness(file.Expires).String(),
+			Size:     file.Size,
+		})
+	}
+
+	slices.SortFunc(entries, func(a, b CacheEntry) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Key, b.Key), cmp.Compare(a.Layer, b.Layer))
+	})
+
+	return &CacheReport{
+		GeneratedAt: time.Now(),
+		DiskEnabled: disk != nil,
+		Entries:     entries,
+	}
+}
@@ -0,0 +1,67 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseCacheRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		wantKind string
+		wantKey  string
+		wantErr  bool
+	}{
+		{ref: "navigation", wantKind: PageKindNavigation, wantKey: navigationCacheKey},
+		{ref: "tutorial:mh29wpicgvh0", wantKind: PageKindTutorial, wantKey: "mh29wpicgvh0"},
+		{ref: "tutorial:guide_v2", wantKind: PageKindTutorial, wantKey: "guide_v2"},
+		{ref: "node_graph:服务器节点_执行节点", wantKind: PageKindNodeGraph, wantKey: "服务器节点_执行节点"},
+		{ref: "tutorial", wantErr: true},
+		{ref: "服务器节点_执行节点", wantErr: true},
+		{ref: "page:abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		kind, key, err := ParseCacheRef(tt.ref)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidCacheKey) {
+				t.Errorf("ParseCacheRef(%q) error = %v, want ErrInvalidCacheKey", tt.ref, err)
+			}
+			continue
+		}
+		if err != nil || kind != tt.wantKind || key != tt.wantKey {
+			t.Errorf("ParseCacheRef(%q) = %q, %q, %v, want %q, %q", tt.ref, kind, key, err, tt.wantKind, tt.wantKey)
+		}
+	}
+}
+
+// TestCacheAdminUsesExplicitKind 教程ID包含下划线时按指定的种类删除和刷新，不会被当作节点类型页面
+func TestCacheAdminUsesExplicitKind(t *testing.T) {
+	const id = "guide_v2"
+	site := newFakeSite(t)
+	site.pages[id] = fakeGuideHTML
+	b := newTestBrowser(t, site, Options{})
+	ctx := context.Background()
+
+	if _, err := b.GetTutorial(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RefreshCache(ctx, PageKindTutorial, id); err != nil {
+		t.Fatalf("RefreshCache: %v", err)
+	}
+	if hits := site.hitCount(id); hits != 2 {
+		t.Errorf("tutorial fetched %d times, want 2", hits)
+	}
+
+	removed, err := b.InvalidateCache(PageKindTutorial, id)
+	if err != nil {
+		t.Fatalf("InvalidateCache: %v", err)
+	}
+	if len(removed) != 1 || removed[0].Kind != PageKindTutorial || removed[0].Layer != CacheLayerMemory {
+		t.Errorf("InvalidateCache removed %+v", removed)
+	}
+	if _, err := b.InvalidateCache(PageKindTutorial, id); !errors.Is(err, ErrCacheEntryNotFound) {
+		t.Errorf("second InvalidateCache error = %v, want ErrCacheEntryNotFound", err)
+	}
+}
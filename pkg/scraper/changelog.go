@@ -0,0 +1,605 @@
+package scraper
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/models"
+)
+
+// 节点变化中的参数方向
+const (
+	ParamDirectionInput  = "input"
+	ParamDirectionOutput = "output"
+)
+
+// 参数变化的种类
+const (
+	ParamAdded              = "added"
+	ParamRemoved            = "removed"
+	ParamRenamed            = "renamed"
+	ParamTypeChanged        = "type_changed"
+	ParamDescriptionChanged = "description_changed"
+)
+
+// 节点类型页面的变化状态
+const (
+	NodePageAdded   = "added"
+	NodePageRemoved = "removed"
+	NodePageChanged = "changed"
+)
+
+// Changelog 两个快照之间的变化
+type Changelog struct {
+	From     string    `json:"from"`      // 旧快照版本名
+	To       string    `json:"to"`        // 新快照版本名
+	FromDate time.Time `json:"from_date"` // 旧快照的抓取时间
+	ToDate   time.Time `json:"to_date"`   // 新快照的抓取时间
+
+	AddedGuides   []GuideRef        `json:"added_guides,omitempty"`
+	RemovedGuides []GuideRef        `json:"removed_guides,omitempty"`
+	UpdatedGuides []GuideRef        `json:"updated_guides,omitempty"` // 内容有变化的教程
+	NodePages     []NodePageChanges `json:"node_pages,omitempty"`     // 有变化的节点类型页面
+	SkippedPages  []string          `json:"skipped_pages,omitempty"`  // 任一快照中获取失败、未参与比较的页面
+}
+
+// GuideRef 教程的ID和标题
+type GuideRef struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// NodePageChanges 单个节点类型页面中的节点变化
+type NodePageChanges struct {
+	ClientType   string       `json:"client_type"`
+	NodeType     string       `json:"node_type"`
+	Status       string       `json:"status"` // added、removed 或 changed
+	AddedNodes   []string     `json:"added_nodes,omitempty"`
+	RemovedNodes []string     `json:"removed_nodes,omitempty"`
+	RenamedNodes []NodeRename `json:"renamed_nodes,omitempty"`
+	ChangedNodes []NodeChange `json:"changed_nodes,omitempty"`
+}
+
+// NodeRename 改名的节点：名称不同，但描述或参数完全相同
+type NodeRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// NodeChange 单个节点的描述和参数变化
+type NodeChange struct {
+	Name           string        `json:"name"`
+	OldName        string        `json:"old_name,omitempty"`        // 节点改名时的旧名称
+	OldDescription string        `json:"old_description,omitempty"` // 仅描述有变化时
+	NewDescription string        `json:"new_description,omitempty"`
+	Params         []ParamChange `json:"params,omitempty"`
+}
+
+// ParamChange 入参或出参的变化
+type ParamChange struct {
+	Direction string `json:"direction"` // input 或 output
+	Change    string `json:"change"`    // added、removed、renamed、type_changed、description_changed
+	Name      string `json:"name"`
+	OldName   string `json:"old_name,omitempty"`
+	OldType   string `json:"old_type,omitempty"`
+	NewType   string `json:"new_type,omitempty"`
+}
+
+// Empty 没有任何变化时返回true
+func (c *Changelog) Empty() bool {
+	return len(c.AddedGuides) == 0 && len(c.RemovedGuides) == 0 && len(c.UpdatedGuides) == 0 && len(c.NodePages) == 0
+}
+
+// DiffSnapshots 比较两个快照中的教程和节点类型页面。
+// 任一快照中获取失败的页面不参与比较，避免把获取失败误报为移除
+func DiffSnapshots(from *Snapshot, to *Snapshot) (*Changelog, error) {
+	changelog := &Changelog{
+		From:     from.Manifest.Version,
+		To:       to.Manifest.Version,
+		FromDate: from.Manifest.StartedAt,
+		ToDate:   to.Manifest.StartedAt,
+	}
+
+	oldEntries, oldFailed := snapshotEntries(from)
+	newEntries, newFailed := snapshotEntries(to)
+
+	for _, key := range sortedKeys(oldEntries, newEntries, oldFailed, newFailed) {
+		if failed, ok := newFailed[key]; ok {
+			changelog.SkippedPages = append(changelog.SkippedPages, entryLabel(failed))
+			continue
+		}
+		if failed, ok := oldFailed[key]; ok {
+			changelog.SkippedPages = append(changelog.SkippedPages, entryLabel(failed))
+			continue
+		}
+		oldEntry, inOld := oldEntries[key]
+		newEntry, inNew := newEntries[key]
+
+		switch kind := entryKind(oldEntry, newEntry); kind {
+		case PageKindTutorial:
+			switch {
+			case !inOld:
+				changelog.AddedGuides = append(changelog.AddedGuides, GuideRef{ID: newEntry.ID, Title: newEntry.Title})
+			case !inNew:
+				changelog.RemovedGuides = append(changelog.RemovedGuides, GuideRef{ID: oldEntry.ID, Title: oldEntry.Title})
+			case oldEntry.SHA256 != newEntry.SHA256:
+				changelog.UpdatedGuides = append(changelog.UpdatedGuides, GuideRef{ID: newEntry.ID, Title: newEntry.Title})
+			}
+
+		case PageKindNodeGraph:
+			if inOld && inNew && oldEntry.SHA256 == newEntry.SHA256 {
+				continue
+			}
+
+			var oldPage, newPage *models.NodeGraphPage
+			var err error
+			if inOld {
+				if oldPage, err = from.NodeGraphPage(oldEntry.ID); err != nil {
+					return nil, err
+				}
+			}
+			if inNew {
+				if newPage, err = to.NodeGraphPage(newEntry.ID); err != nil {
+					return nil, err
+				}
+			}
+
+			entry := newEntry
+			if !inNew {
+				entry = oldEntry
+			}
+			if changes, ok := diffNodePages(entry.ClientType, entry.NodeType, oldPage, newPage); ok {
+				changelog.NodePages = append(changelog.NodePages, changes)
+			}
+		}
+	}
+
+	return changelog, nil
+}
+
+// snapshotEntries 按页面种类和标识索引快照中的教程和节点类型页面，同时返回获取失败的页面
+func snapshotEntries(snapshot *Snapshot) (map[string]SnapshotEntry, map[string]SnapshotEntry) {
+	entries := make(map[string]SnapshotEntry)
+	failed := make(map[string]SnapshotEntry)
+
+	for _, entry := range snapshot.Manifest.Entries {
+		var key string
+		switch entry.Kind {
+		case PageKindTutorial:
+			key = entry.Kind + "/" + entry.ID
+		case PageKindNodeGraph:
+			// 节点类型页面按类型名比较，页面ID变化不影响比较
+			key = entry.Kind + "/" + nodeGraphPageName(entry.ClientType, entry.NodeType)
+		default:
+			continue
+		}
+
+		if entry.Error != "" {
+			failed[key] = entry
+			continue
+		}
+		entries[key] = entry
+	}
+
+	return entries, failed
+}
+
+// sortedKeys 返回所有映射中键的有序并集
+func sortedKeys(indexes ...map[string]SnapshotEntry) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, index := range indexes {
+		for key := range index {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// entryLabel 返回页面便于阅读的名称：节点类型页面为 客户端类型/节点类型，教程为 标题 (ID)
+func entryLabel(entry SnapshotEntry) string {
+	switch {
+	case entry.Kind == PageKindNodeGraph:
+		return nodeGraphPageName(entry.ClientType, entry.NodeType)
+	case entry.Title != "":
+		return fmt.Sprintf("%s (%s)", entry.Title, entry.ID)
+	default:
+		return entry.ID
+	}
+}
+
+// entryKind 返回存在的条目的页面种类
+func entryKind(a SnapshotEntry, b SnapshotEntry) string {
+	if a.Kind != "" {
+		return a.Kind
+	}
+	return b.Kind
+}
+
+// diffNodePages 比较同一节点类型的新旧页面，页面不存在时传nil
+func diffNodePages(clientType string, nodeType string, oldPage *models.NodeGraphPage, newPage *models.NodeGraphPage) (NodePageChanges, bool) {
+	changes := NodePageChanges{ClientType: clientType, NodeType: nodeType, Status: NodePageChanged}
+
+	switch {
+	case oldPage == nil:
+		changes.Status = NodePageAdded
+		oldPage = &models.NodeGraphPage{}
+	case newPage == nil:
+		changes.Status = NodePageRemoved
+		newPage = &models.NodeGraphPage{}
+	}
+
+	oldNodes := nodesByName(oldPage.Nodes)
+	newNodes := nodesByName(newPage.Nodes)
+
+	var removed, added []*models.NodeGraphDetails
+	for _, node := range oldPage.Nodes {
+		if _, ok := newNodes[node.NodeName]; !ok {
+			removed = append(removed, node)
+		}
+	}
+	for _, node := range newPage.Nodes {
+		oldNode, ok := oldNodes[node.NodeName]
+		if !ok {
+			added = append(added, node)
+			continue
+		}
+		if change, changed := diffNode(oldNode, node); changed {
+			changes.ChangedNodes = append(changes.ChangedNodes, change)
+		}
+	}
+
+	// 描述或参数完全相同的一对移除、新增节点视为改名
+	paired := make(map[*models.NodeGraphDetails]bool)
+	for _, oldNode := range removed {
+		index := slices.IndexFunc(added, func(newNode *models.NodeGraphDetails) bool {
+			return !paired[newNode] && sameNode(oldNode, newNode)
+		})
+		if index < 0 {
+			changes.RemovedNodes = append(changes.RemovedNodes, oldNode.NodeName)
+			continue
+		}
+
+		newNode := added[index]
+		paired[newNode] = true
+		changes.RenamedNodes = append(changes.RenamedNodes, NodeRename{From: oldNode.NodeName, To: newNode.NodeName})
+		if change, changed := diffNode(oldNode, newNode); changed {
+			change.OldName = oldNode.NodeName
+			changes.ChangedNodes = append(changes.ChangedNodes, change)
+		}
+	}
+	for _, newNode := range added {
+		if !paired[newNode] {
+			changes.AddedNodes = append(changes.AddedNodes, newNode.NodeName)
+		}
+	}
+
+	changed := len(changes.AddedNodes) > 0 || len(changes.RemovedNodes) > 0 || len(changes.RenamedNodes) > 0 || len(changes.ChangedNodes) > 0
+	return changes, changed || changes.Status != NodePageChanged
+}
+
+// nodesByName 按名称索引节点
+func nodesByName(nodes []*models.NodeGraphDetails) map[string]*models.NodeGraphDetails {
+	byName := make(map[string]*models.NodeGraphDetails, len(nodes))
+	for _, node := range nodes {
+		byName[node.NodeName] = node
+	}
+	return byName
+}
+
+// sameNode 判断名称不同的两个节点是否是同一个节点：描述相同，或入参出参的名称和类型完全相同
+func sameNode(a *models.NodeGraphDetails, b *models.NodeGraphDetails) bool {
+	if a.Description != "" && a.Description == b.Description {
+		return true
+	}
+	signature := func(node *models.NodeGraphDetails) string {
+		var sb strings.Builder
+		for _, param := range node.Inputs {
+			sb.WriteString("in:" + param.Name + ":" + param.Type + ";")
+		}
+		for _, param := range node.Outputs {
+			sb.WriteString("out:" + param.Name + ":" + param.Type + ";")
+		}
+		return sb.String()
+	}
+	sig := signature(a)
+	return sig != "" && sig == signature(b)
+}
+
+// diffNode 比较节点的描述、入参和出参
+func diffNode(oldNode *models.NodeGraphDetails, newNode *models.NodeGraphDetails) (NodeChange, bool) {
+	change := NodeChange{Name: newNode.NodeName}
+
+	if oldNode.Description != newNode.Description {
+		change.OldDescription = oldNode.Description
+		change.NewDescription = newNode.Description
+	}
+	change.Params = append(change.Params, diffParams(ParamDirectionInput, oldNode.Inputs, newNode.Inputs)...)
+	change.Params = append(change.Params, diffParams(ParamDirectionOutput, oldNode.Outputs, newNode.Outputs)...)
+
+	return change, oldNode.Description != newNode.Description || len(change.Params) > 0
+}
+
+// diffParams 比较同一方向的参数：同名参数比较类型和说明；
+// 剩余的移除、新增参数如果位于同一位置则视为改名
+func diffParams(direction string, oldParams []models.Param, newParams []models.Param) []ParamChange {
+	var changes []ParamChange
+
+	newByName := make(map[string]models.Param, len(newParams))
+	for _, param := range newParams {
+		newByName[param.Name] = param
+	}
+	oldByName := make(map[string]models.Param, len(oldParams))
+	for _, param := range oldParams {
+		oldByName[param.Name] = param
+	}
+
+	for _, oldParam := range oldParams {
+		newParam, ok := newByName[oldParam.Name]
+		if !ok {
+			continue
+		}
+		if oldParam.Type != newParam.Type {
+			changes = append(changes, ParamChange{
+				Direction: direction,
+				Change:    ParamTypeChanged,
+				Name:      newParam.Name,
+				OldType:   oldParam.Type,
+				NewType:   newParam.Type,
+			})
+		}
+		if oldParam.Description != newParam.Description {
+			changes = append(changes, ParamChange{Direction: direction, Change: ParamDescriptionChanged, Name: newParam.Name})
+		}
+	}
+
+	renamed := make(map[int]bool)
+	for i, oldParam := range oldParams {
+		if _, ok := newByName[oldParam.Name]; ok {
+			continue
+		}
+		if i < len(newParams) {
+			if _, exists := oldByName[newParams[i].Name]; !exists {
+				renamed[i] = true
+				change := ParamChange{Direction: direction, Change: ParamRenamed, Name: newParams[i].Name, OldName: oldParam.Name}
+				if oldParam.Type != newParams[i].Type {
+					change.OldType = oldParam.Type
+					change.NewType = newParams[i].Type
+				}
+				changes = append(changes, change)
+				continue
+			}
+		}
+		changes = append(changes, ParamChange{Direction: direction, Change: ParamRemoved, Name: oldParam.Name, OldType: oldParam.Type})
+	}
+
+	for i, newParam := range newParams {
+		if _, ok := oldByName[newParam.Name]; ok || renamed[i] {
+			continue
+		}
+		changes = append(changes, ParamChange{Direction: direction, Change: ParamAdded, Name: newParam.Name, NewType: newParam.Type})
+	}
+
+	return changes
+}
+
+// ChangelogSince 比较快照根目录中指定时间之前的最后一个可比较的快照与最新的可比较快照，
+// 有页面获取失败但抓取已结束的快照也参与比较，失败的页面记录在 SkippedPages 中。
+// 指定时间早于所有快照时从最早的可比较快照开始比较
+func ChangelogSince(root string, since time.Time) (*Changelog, error) {
+	comparable, err := comparableSnapshots(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(comparable) == 0 {
+		return nil, fmt.Errorf("%w: no finished snapshot in %s", ErrSnapshotNotFound, root)
+	}
+
+	from := comparable[0]
+	for _, snapshot := range comparable {
+		if snapshot.Manifest.StartedAt.After(since) {
+			break
+		}
+		from = snapshot
+	}
+
+	return DiffSnapshots(from, comparable[len(comparable)-1])
+}
+
+// comparableSnapshots 返回根目录下可用于比较的快照，按版本名从旧到新排序
+func comparableSnapshots(root string) ([]*Snapshot, error) {
+	snapshots, err := ListSnapshots(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var comparable []*Snapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Manifest.Comparable() {
+			comparable = append(comparable, snapshot)
+		}
+	}
+	return comparable, nil
+}
+
+// LatestComparableSnapshot 返回最新的可用于比较的快照
+func LatestComparableSnapshot(root string) (*Snapshot, error) {
+	comparable, err := comparableSnapshots(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(comparable) == 0 {
+		return nil, fmt.Errorf("%w: no finished snapshot in %s", ErrSnapshotNotFound, root)
+	}
+	return comparable[len(comparable)-1], nil
+}
+
+// ResolveSnapshot 按版本名或目录路径查找快照，为空时返回最新的完整快照
+func ResolveSnapshot(root string, name string) (*Snapshot, error) {
+	if name != "" {
+		if snapshot, err := OpenSnapshot(name); err == nil {
+			return snapshot, nil
+		}
+		snapshot, err := OpenSnapshot(filepath.Join(root, name))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrSnapshotNotFound, name, err)
+		}
+		return snapshot, nil
+	}
+
+	snapshots, err := ListSnapshots(root)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].Manifest.Complete {
+			return snapshots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no complete snapshot in %s", ErrSnapshotNotFound, root)
+}
+
+// PreviousSnapshot 返回版本名早于 to 的最后一个可用于比较的快照
+func PreviousSnapshot(root string, to *Snapshot) (*Snapshot, error) {
+	comparable, err := comparableSnapshots(root)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(comparable) - 1; i >= 0; i-- {
+		if comparable[i].Manifest.Version < to.Manifest.Version {
+			return comparable[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no finished snapshot before %s in %s", ErrSnapshotNotFound, to.Manifest.Version, root)
+}
+
+// ParseSince 解析日期参数，支持 2006-01-02、2006-01-02 15:04:05 和RFC3339格式，未指定时区时使用本地时区
+func ParseSince(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.DateOnly, time.DateTime, time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+}
+
+// Markdown 将变化格式化为Markdown
+func (c *Changelog) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# 更新日志\n\n")
+	sb.WriteString(fmt.Sprintf("快照 %s（%s）→ %s（%s）\n\n", c.From, c.FromDate.Format(time.DateTime), c.To, c.ToDate.Format(time.DateTime)))
+
+	if c.From == c.To {
+		sb.WriteString("只有一个可用的快照，无法比较。请在之后再次运行 crawl 生成新的快照\n")
+		return sb.String()
+	}
+	if c.Empty() {
+		sb.WriteString("没有变化\n")
+		if len(c.SkippedPages) > 0 {
+			sb.WriteString("\n")
+			c.writeSkipped(&sb)
+		}
+		return sb.String()
+	}
+
+	writeGuides := func(title string, guides []GuideRef) {
+		if len(guides) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("## %s\n\n", title))
+		for _, guide := range guides {
+			sb.WriteString(fmt.Sprintf("- %s (`%s`)\n", guide.Title, guide.ID))
+		}
+		sb.WriteString("\n")
+	}
+	writeGuides("新增教程", c.AddedGuides)
+	writeGuides("移除教程", c.RemovedGuides)
+	writeGuides("内容更新的教程", c.UpdatedGuides)
+
+	if len(c.NodePages) > 0 {
+		sb.WriteString("## 节点变化\n\n")
+	}
+	for _, page := range c.NodePages {
+		sb.WriteString(fmt.Sprintf("### %s\n\n", nodeGraphPageName(page.ClientType, page.NodeType)))
+		switch page.Status {
+		case NodePageAdded:
+			sb.WriteString("新增的节点类型页面\n\n")
+		case NodePageRemoved:
+			sb.WriteString("节点类型页面已移除\n\n")
+		}
+
+		if len(page.AddedNodes) > 0 {
+			sb.WriteString(fmt.Sprintf("- 新增节点: %s\n", strings.Join(page.AddedNodes, "、")))
+		}
+		if len(page.RemovedNodes) > 0 {
+			sb.WriteString(fmt.Sprintf("- 移除节点: %s\n", strings.Join(page.RemovedNodes, "、")))
+		}
+		for _, rename := range page.RenamedNodes {
+			sb.WriteString(fmt.Sprintf("- 节点改名: %s → %s\n", rename.From, rename.To))
+		}
+		for _, node := range page.ChangedNodes {
+			sb.WriteString(fmt.Sprintf("- %s\n", node.Name))
+			if node.OldDescription != "" || node.NewDescription != "" {
+				sb.WriteString(fmt.Sprintf("  - 描述: %s → %s\n", orNone(node.OldDescription), orNone(node.NewDescription)))
+			}
+			for _, param := range node.Params {
+				sb.WriteString("  - " + param.markdown() + "\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	c.writeSkipped(&sb)
+	return sb.String()
+}
+
+// writeSkipped 列出获取失败、未参与比较的页面，这些页面的变化不在报告中
+func (c *Changelog) writeSkipped(sb *strings.Builder) {
+	if len(c.SkippedPages) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("## 未比较的页面\n\n以下 %d 个页面在快照中获取失败，未参与比较：\n\n", len(c.SkippedPages)))
+	for _, page := range c.SkippedPages {
+		sb.WriteString("- " + page + "\n")
+	}
+}
+
+// markdown 将参数变化格式化为一行说明
+func (p ParamChange) markdown() string {
+	direction := "入参"
+	if p.Direction == ParamDirectionOutput {
+		direction = "出参"
+	}
+
+	switch p.Change {
+	case ParamAdded:
+		return fmt.Sprintf("新增%s `%s`（%s）", direction, p.Name, p.NewType)
+	case ParamRemoved:
+		return fmt.Sprintf("移除%s `%s`（%s）", direction, p.Name, p.OldType)
+	case ParamRenamed:
+		if p.OldType != p.NewType {
+			return fmt.Sprintf("%s改名 `%s` → `%s`，类型 %s → %s", direction, p.OldName, p.Name, p.OldType, p.NewType)
+		}
+		return fmt.Sprintf("%s改名 `%s` → `%s`", direction, p.OldName, p.Name)
+	case ParamTypeChanged:
+		return fmt.Sprintf("%s `%s` 类型 %s → %s", direction, p.Name, p.OldType, p.NewType)
+	default:
+		return fmt.Sprintf("%s `%s` 说明有变化", direction, p.Name)
+	}
+}
+
+// orNone 空字符串显示为（无）
+func orNone(s string) string {
+	if s == "" {
+		return "（无）"
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestSnapshot 在根目录下写入只包含清单的快照，finished 为false时模拟中断的抓取
+func writeTestSnapshot(t *testing.T, root string, version string, finished bool, entries ...SnapshotEntry) {
+	t.Helper()
+
+	startedAt, err := time.ParseInLocation(snapshotVersionLayout, version, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := SnapshotManifest{
+		SchemaVersion: SnapshotSchemaVersion,
+		Version:       version,
+		StartedAt:     startedAt,
+		Entries:       append([]SnapshotEntry{{Kind: PageKindNavigation, ID: navigationPageID, Path: snapshotNavigationFile}}, entries...),
+	}
+	if finished {
+		finishedAt := startedAt.Add(time.Minute)
+		manifest.FinishedAt = &finishedAt
+		manifest.Complete = manifest.Failed() == 0
+		if manifest.Complete {
+			manifest.CompletedAt = &finishedAt
+		}
+	}
+
+	dir := filepath.Join(root, version)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, snapshotManifestFile), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testGuideEntry 快照中的教程条目，失败原因不为空时表示获取失败
+func testGuideEntry(id string, title string, hash string, fetchErr string) SnapshotEntry {
+	return SnapshotEntry{Kind: PageKindTutorial, ID: id, Title: title, Path: snapshotGuidePath(id), SHA256: hash, Error: fetchErr}
+}
+
+func TestChangelogSinceUsesFinishedSnapshots(t *testing.T) {
+	root := t.TempDir()
+	writeTestSnapshot(t, root, "20250101-000000", true,
+		testGuideEntry("guide1", "教程一", "a", ""),
+		testGuideEntry("guide2", "教程二", "b", ""),
+	)
+	// 教程二获取失败，但抓取已结束，仍参与比较
+	writeTestSnapshot(t, root, "20250201-000000", true,
+		testGuideEntry("guide1", "教程一", "a2", ""),
+		testGuideEntry("guide2", "教程二", "", "navigation timeout"),
+	)
+	// 中断的抓取缺少大部分页面，不参与比较
+	writeTestSnapshot(t, root, "20250301-000000", false,
+		testGuideEntry("guide1", "教程一", "a3", ""),
+	)
+
+	changelog, err := ChangelogSince(root, time.Date(2025, 1, 15, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changelog.From != "20250101-000000" || changelog.To != "20250201-000000" {
+		t.Fatalf("compared %s → %s, want 20250101-000000 → 20250201-000000", changelog.From, changelog.To)
+	}
+	if len(changelog.RemovedGuides) != 0 {
+		t.Errorf("failed page reported as removed: %v", changelog.RemovedGuides)
+	}
+	if want := []GuideRef{{ID: "guide1", Title: "教程一"}}; !slices.Equal(changelog.UpdatedGuides, want) {
+		t.Errorf("UpdatedGuides = %v, want %v", changelog.UpdatedGuides, want)
+	}
+	if want := []string{"教程二 (guide2)"}; !slices.Equal(changelog.SkippedPages, want) {
+		t.Errorf("SkippedPages = %v, want %v", changelog.SkippedPages, want)
+	}
+	if !strings.Contains(changelog.Markdown(), "- 教程二 (guide2)\n") {
+		t.Errorf("markdown does not list the skipped page:\n%s", changelog.Markdown())
+	}
+}
+
+func TestComparableSnapshots(t *testing.T) {
+	root := t.TempDir()
+	writeTestSnapshot(t, root, "20250101-000000", false)
+	if _, err := LatestComparableSnapshot(root); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Fatalf("interrupted snapshot accepted, err = %v", err)
+	}
+
+	writeTestSnapshot(t, root, "20250201-000000", true, testGuideEntry("guide1", "教程一", "", "http 503"))
+	latest, err := LatestComparableSnapshot(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Manifest.Version != "20250201-000000" {
+		t.Errorf("latest comparable snapshot = %s, want 20250201-000000", latest.Manifest.Version)
+	}
+	if _, err := PreviousSnapshot(root, latest); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("PreviousSnapshot returned the interrupted snapshot, err = %v", err)
+	}
+}
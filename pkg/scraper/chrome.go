@@ -0,0 +1,127 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// ChromeOptions Chromium连接和启动配置
+type ChromeOptions struct {
+	ControlURL  string   // 已运行Chromium的DevTools地址，如 ws://host:9222/devtools/browser/xxx 或 http://host:9222
+	Bin         string   // 本地启动时使用的Chromium可执行文件路径，为空时自动查找或下载
+	UserDataDir string   // 本地启动时的用户数据目录，为空时使用临时目录
+	Proxy       string   // 本地启动时使用的代理，如 127.0.0.1:8080
+	Flags       []string // 本地启动时附加的命令行参数，如 disable-gpu、window-size=1280,800
+}
+
+// chromeSession 一次Chromium连接，记录关闭时需要释放的资源
+type chromeSession struct {
+	browser    *rod.Browser
+	launcher   *launcher.Launcher // 本地启动时非空
+	remote     bool               // 是否连接的是共享的远程Chromium
+	disconnect context.CancelFunc // 断开与远程Chromium的连接，本地启动时为nil
+}
+
+// connectChrome 连接Chromium：配置了DevTools地址时优先连接已有实例，失败后回退到本地启动
+func connectChrome(opts ChromeOptions) (*chromeSession, error) {
+	if opts.ControlURL != "" {
+		session, err := connectRemoteChrome(opts.ControlURL)
+		if err == nil {
+			utils.Info("Connected to remote chromium", "control_url", opts.ControlURL)
+			return session, nil
+		}
+		utils.Error("Failed to connect to remote chromium, falling back to local launch", "control_url", opts.ControlURL, "error", err)
+	}
+
+	return launchChrome(opts)
+}
+
+// connectRemoteChrome 连接已运行的Chromium，并在独立的浏览器上下文中工作，避免影响共享实例上的其它使用者
+func connectRemoteChrome(controlURL string) (*chromeSession, error) {
+	wsURL, err := launcher.ResolveURL(controlURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve devtools url %s: %w", controlURL, err)
+	}
+
+	// 连接绑定到可取消的ctx，取消时断开连接而不关闭共享的远程实例
+	ctx, disconnect := context.WithCancel(context.Background())
+	browser := rod.New().Context(ctx).ControlURL(wsURL)
+	if err := browser.Connect(); err != nil {
+		disconnect()
+		return nil, fmt.Errorf("failed to connect to %s: %w", wsURL, err)
+	}
+
+	incognito, err := browser.Incognito()
+	if err != nil {
+		disconnect()
+		return nil, fmt.Errorf("failed to create browser context on %s: %w", wsURL, err)
+	}
+
+	return &chromeSession{
+		browser:    incognito,
+		remote:     true,
+		disconnect: disconnect,
+	}, nil
+}
+
+// launchChrome 在本地启动Chromium
+func launchChrome(opts ChromeOptions) (*chromeSession, error) {
+	l := launcher.New()
+	if opts.Bin != "" {
+		l = l.Bin(opts.Bin)
+	}
+	if opts.UserDataDir != "" {
+		l = l.UserDataDir(opts.UserDataDir)
+	}
+	if opts.Proxy != "" {
+		l = l.Proxy(opts.Proxy)
+	}
+	for _, flag := range opts.Flags {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+		if hasValue {
+			l = l.Set(flags.Flag(name), value)
+		} else {
+			l = l.Set(flags.Flag(name))
+		}
+	}
+
+	controlURL, err := l.Launch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to launch chromium: %w", err)
+	}
+
+	browser := rod.New().ControlURL(controlURL)
+	if err := browser.Connect(); err != nil {
+		l.Kill()
+		return nil, fmt.Errorf("failed to connect to launched chromium: %w", err)
+	}
+
+	utils.Info("Launched local chromium", "bin", opts.Bin, "user_data_dir", opts.UserDataDir, "proxy", opts.Proxy, "flags", opts.Flags)
+	return &chromeSession{
+		browser:  browser,
+		launcher: l,
+	}, nil
+}
+
+// close 关闭连接：远程实例只释放自己的浏览器上下文并断开连接，本地实例关闭进程并清理临时数据目录。
+// 浏览器卡死时关闭请求超时后放弃，本地进程直接结束，不会一直阻塞
+func (s *chromeSession) close(keepUserDataDir bool) error {
+	err := s.browser.Timeout(browserCloseTimeout).Close()
+	if s.disconnect != nil {
+		s.disconnect()
+	}
+	if s.launcher != nil {
+		// 进程结束后 Cleanup 等待退出才不会阻塞
+		s.launcher.Kill()
+		if !keepUserDataDir {
+			s.launcher.Cleanup()
+		}
+	}
+	return err
+}
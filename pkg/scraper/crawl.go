@@ -0,0 +1,409 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/models"
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// 全站抓取的默认配置
+const (
+	// DefaultCrawlConcurrency 默认同时获取的页面数
+	DefaultCrawlConcurrency = 2
+
+	// DefaultCrawlInterval 默认相邻两次页面请求的最小间隔
+	DefaultCrawlInterval = 500 * time.Millisecond
+)
+
+// CrawlOptions 全站抓取配置
+type CrawlOptions struct {
+	OutputDir   string        // 快照根目录，每个快照是其下以版本名命名的子目录
+	Version     string        // 快照版本名，为空时使用当前时间；目录已存在时续抓未完成的页面
+	Concurrency int           // 同时获取的页面数，<=0 时使用默认值
+	Interval    time.Duration // 相邻两次页面请求（包括重试）的最小间隔，<0 时不限速，0 时使用默认值
+
+	Progress func(CrawlProgress) // 每个页面处理完成后调用，可为空
+}
+
+// CrawlProgress 抓取进度
+type CrawlProgress struct {
+	Done    int           // 已处理的页面数，包括续抓时跳过的页面
+	Total   int           // 页面总数
+	Skipped bool          // 该页面在之前的抓取中已完成
+	Entry   SnapshotEntry // 刚处理完的页面
+}
+
+// crawlJob 待抓取的页面
+type crawlJob struct {
+	entry SnapshotEntry
+	fetch func(ctx context.Context) (any, error)
+}
+
+// crawler 单次抓取的状态
+type crawler struct {
+	browser  *Browser
+	dir      string
+	opts     CrawlOptions
+	mu       sync.Mutex
+	manifest *SnapshotManifest
+	entries  map[string]SnapshotEntry // 按文件路径索引的清单条目
+	done     int
+	total    int
+}
+
+// Crawl 遍历导航目录，获取所有教程和节点类型页面，写入版本化的快照目录：
+// 每个页面一个JSON文件，清单中记录各文件的哈希和获取时间。
+// 每个页面写入后都会保存清单，中断后以相同版本名再次运行会跳过已完成的页面。
+// 单个页面获取失败记录在清单中，不中断抓取。抓取的页面不写入内存和磁盘缓存，不影响服务使用的缓存
+func (b *Browser) Crawl(ctx context.Context, opts CrawlOptions) (*SnapshotManifest, error) {
+	if opts.Version == "" {
+		opts.Version = time.Now().Format(snapshotVersionLayout)
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultCrawlConcurrency
+	}
+	if opts.Interval == 0 {
+		opts.Interval = DefaultCrawlInterval
+	}
+
+	c := &crawler{
+		browser: b,
+		dir:     filepath.Join(opts.OutputDir, opts.Version),
+		opts:    opts,
+		entries: make(map[string]SnapshotEntry),
+	}
+	if err := c.open(); err != nil {
+		return nil, err
+	}
+
+	if opts.Interval > 0 {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		ctx = withRequestLimiter(ctx, ticker.C)
+	}
+
+	utils.Info("Starting crawl", "dir", c.dir, "concurrency", opts.Concurrency, "interval", opts.Interval, "resumed_entries", len(c.entries))
+
+	items, err := c.navigation(ctx)
+	if err != nil {
+		return c.manifest, err
+	}
+
+	jobs := c.jobs(items)
+	c.mu.Lock()
+	c.total = len(jobs) + 1
+	c.mu.Unlock()
+
+	queue := make(chan crawlJob)
+	var wg sync.WaitGroup
+	for range opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				c.run(ctx, job)
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		if c.completed(job.entry.Path) {
+			c.finish(job.entry, true)
+			continue
+		}
+		select {
+		case queue <- job:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return c.manifest, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	finishedAt := time.Now()
+	c.manifest.FinishedAt = &finishedAt
+	c.manifest.Complete = c.manifest.Failed() == 0
+	if c.manifest.Complete {
+		c.manifest.CompletedAt = &finishedAt
+	}
+	if err := c.save(); err != nil {
+		return c.manifest, err
+	}
+
+	utils.Info("Crawl completed", "dir", c.dir, "pages", len(c.manifest.Entries), "failed", c.manifest.Failed())
+	return c.manifest, nil
+}
+
+// open 创建快照目录，目录中已有清单时读取以便续抓
+func (c *crawler) open() error {
+	for _, dir := range []string{c.dir, filepath.Join(c.dir, snapshotGuidesDir), filepath.Join(c.dir, snapshotNodesDir)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+	}
+
+	snapshot, err := OpenSnapshot(c.dir)
+	if err != nil {
+		if _, statErr := os.Stat(filepath.Join(c.dir, snapshotManifestFile)); statErr == nil {
+			// 清单存在但无法读取，不覆盖
+			return err
+		}
+		c.manifest = &SnapshotManifest{
+			SchemaVersion: SnapshotSchemaVersion,
+			Version:       c.opts.Version,
+			BaseURL:       c.browser.baseURL,
+			StartedAt:     time.Now(),
+		}
+		return nil
+	}
+
+	if snapshot.Manifest.BaseURL != c.browser.baseURL {
+		return fmt.Errorf("snapshot %s was crawled from %s, not %s", c.dir, snapshot.Manifest.BaseURL, c.browser.baseURL)
+	}
+
+	c.manifest = snapshot.Manifest
+	c.manifest.Complete = false
+	c.manifest.CompletedAt = nil
+	c.manifest.FinishedAt = nil
+	for _, entry := range c.manifest.Entries {
+		c.entries[entry.Path] = entry
+	}
+	return nil
+}
+
+// navigation 获取导航目录并写入快照，续抓时优先使用快照中的导航目录，保证页面列表不变
+func (c *crawler) navigation(ctx context.Context) ([]models.NavigationItem, error) {
+	if c.completed(snapshotNavigationFile) {
+		snapshot := &Snapshot{Dir: c.dir, Manifest: c.manifest}
+		if items, err := snapshot.Navigation(); err == nil {
+			c.finish(c.entries[snapshotNavigationFile], true)
+			return items, nil
+		}
+	}
+
+	var items []models.NavigationItem
+	entry := SnapshotEntry{Kind: PageKindNavigation, ID: navigationPageID, Path: snapshotNavigationFile}
+	c.run(ctx, crawlJob{entry: entry, fetch: func(ctx context.Context) (any, error) {
+		var err error
+		items, err = c.browser.scrapeNavigation(ctx)
+		return items, err
+	}})
+
+	if items == nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to crawl navigation: %s", c.entries[snapshotNavigationFile].Error)
+	}
+	return items, nil
+}
+
+// jobs 根据导航目录生成所有教程和节点类型页面的抓取任务
+func (c *crawler) jobs(items []models.NavigationItem) []crawlJob {
+	var jobs []crawlJob
+	seen := map[string]bool{}
+
+	for _, page := range FlattenNavigation(items) {
+		id := page.URL
+		path := snapshotGuidePath(id)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		jobs = append(jobs, crawlJob{
+			entry: SnapshotEntry{Kind: PageKindTutorial, ID: id, Title: page.Title, Path: path},
+			fetch: func(ctx context.Context) (any, error) {
+				tutorial, err := c.browser.scrapeTutorial(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				// 快照文件不含获取时间，哈希只随内容变化
+				stored := *tutorial
+				stored.LastUpdated = time.Time{}
+				stored.CacheExpiry = time.Time{}
+				return &stored, nil
+			},
+		})
+	}
+
+	// 节点类型直接从本次的导航目录发现，不再重复获取
+	c.browser.updateNodeTypes(items)
+	nodeTypes := c.browser.KnownNodeTypes()
+	for _, clientType := range nodeTypes.ClientTypes() {
+		for _, nodeType := range nodeTypes.Types(clientType) {
+			id := nodeTypes[clientType][nodeType]
+			path := snapshotNodePath(id)
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			jobs = append(jobs, crawlJob{
+				entry: SnapshotEntry{Kind: PageKindNodeGraph, ID: id, ClientType: clientType, NodeType: nodeType, Path: path},
+				fetch: func(ctx context.Context) (any, error) {
+					page, err := c.browser.scrapeNodeGraphPage(ctx, clientType, nodeType, id)
+					if err != nil {
+						return nil, err
+					}
+					return storedNodeGraphPage(page), nil
+				},
+			})
+		}
+	}
+
+	return jobs
+}
+
+// storedNodeGraphPage 复制节点类型页面并去掉获取时间
+func storedNodeGraphPage(page *models.NodeGraphPage) *models.NodeGraphPage {
+	stored := *page
+	stored.LastUpdated = time.Time{}
+	stored.Nodes = make([]*models.NodeGraphDetails, 0, len(page.Nodes))
+	for _, node := range page.Nodes {
+		copied := *node
+		copied.LastUpdated = time.Time{}
+		stored.Nodes = append(stored.Nodes, &copied)
+	}
+	return &stored
+}
+
+// run 获取页面并写入快照文件，结果记入清单。限速由 fetchDocument 在每次请求前等待，重试的请求也受限速
+func (c *crawler) run(ctx context.Context, job crawlJob) {
+	entry := job.entry
+	entry.FetchedAt = time.Now()
+
+	err := c.write(ctx, &entry, job.fetch)
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err != nil {
+		utils.Error("Failed to crawl page", "kind", entry.Kind, "id", entry.ID, "error", err)
+		entry.Error = err.Error()
+	}
+
+	c.finish(entry, false)
+}
+
+// write 获取页面并写入快照文件，记录文件的哈希和大小
+func (c *crawler) write(ctx context.Context, entry *SnapshotEntry, fetch func(ctx context.Context) (any, error)) error {
+	v, err := fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, sum, err := encodeSnapshotFile(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", entry.Path, err)
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, filepath.FromSlash(entry.Path)), data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", entry.Path, err)
+	}
+
+	entry.SHA256 = sum
+	entry.Size = len(data)
+	return nil
+}
+
+// completed 判断页面在之前的抓取中是否已成功完成，且文件未被修改
+func (c *crawler) completed(path string) bool {
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	c.mu.Unlock()
+	if !ok || entry.Error != "" || entry.SHA256 == "" {
+		return false
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.dir, filepath.FromSlash(path)))
+	if err != nil {
+		return false
+	}
+	return contentHash(data) == entry.SHA256
+}
+
+// finish 更新清单并保存，然后报告进度
+func (c *crawler) finish(entry SnapshotEntry, skipped bool) {
+	c.mu.Lock()
+	if !skipped {
+		c.entries[entry.Path] = entry
+		c.manifest.Entries = slices.SortedFunc(func(yield func(SnapshotEntry) bool) {
+			for _, e := range c.entries {
+				if !yield(e) {
+					return
+				}
+			}
+		}, func(a, b SnapshotEntry) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+		if err := c.save(); err != nil {
+			utils.Error("Failed to save snapshot manifest", "dir", c.dir, "error", err)
+		}
+	}
+	c.done++
+	c.mu.Unlock()
+
+	c.report(entry, skipped)
+}
+
+// report 调用进度回调
+func (c *crawler) report(entry SnapshotEntry, skipped bool) {
+	if c.opts.Progress == nil {
+		return
+	}
+	c.mu.Lock()
+	progress := CrawlProgress{Done: c.done, Total: c.total, Skipped: skipped, Entry: entry}
+	c.mu.Unlock()
+	c.opts.Progress(progress)
+}
+
+// save 保存清单，调用方需持有锁
+func (c *crawler) save() error {
+	data, _, err := encodeSnapshotFile(c.manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot manifest: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, snapshotManifestFile), data); err != nil {
+		return fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	return nil
+}
+
+// requestLimiterKey ctx中页面请求限速器的key
+type requestLimiterKey struct{}
+
+// withRequestLimiter 返回带限速器的ctx，每次页面请求（包括重试）前从 limiter 取得一个名额
+func withRequestLimiter(ctx context.Context, limiter <-chan time.Time) context.Context {
+	return context.WithValue(ctx, requestLimiterKey{}, limiter)
+}
+
+// waitRequestLimiter 等待ctx中限速器的下一个名额，没有限速器时立即返回，ctx取消时返回取消原因
+func waitRequestLimiter(ctx context.Context) error {
+	limiter, ok := ctx.Value(requestLimiterKey{}).(<-chan time.Time)
+	if !ok {
+		return nil
+	}
+	select {
+	case <-limiter:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestRequestLimiterCoversRetries 限速作用于每次页面请求，重试的请求同样要取得名额
+func TestRequestLimiterCoversRetries(t *testing.T) {
+	site := newFakeSite(t)
+	site.fail(fakeGuideID, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	b := newTestBrowser(t, site, Options{Retry: fastRetryPolicy(3)})
+
+	tokens := make(chan time.Time, 3)
+	for range cap(tokens) {
+		tokens <- time.Now()
+	}
+	ctx := withRequestLimiter(context.Background(), tokens)
+
+	if _, err := b.scrapeTutorial(ctx, fakeGuideID); err != nil {
+		t.Fatal(err)
+	}
+	if hits := site.hitCount(fakeGuideID); hits != 3 {
+		t.Fatalf("tutorial fetched %d times, want 3", hits)
+	}
+	if left := len(tokens); left != 0 {
+		t.Errorf("%d limiter tokens left after 3 requests, want 0", left)
+	}
+
+	// 没有名额时请求等待到ctx结束
+	site.fail(fakeGuideID, http.StatusServiceUnavailable)
+	ctx, cancel := context.WithTimeout(withRequestLimiter(context.Background(), tokens), 50*time.Millisecond)
+	defer cancel()
+	if _, err := b.scrapeTutorial(ctx, fakeGuideID); err == nil {
+		t.Fatal("scrapeTutorial succeeded without a limiter token")
+	}
+	if hits := site.hitCount(fakeGuideID); hits != 3 {
+		t.Errorf("tutorial fetched %d times without a limiter token, want 3", hits)
+	}
+}
@@ -0,0 +1,195 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// 磁盘缓存相关常量
+const (
+	// DiskCacheSchemaVersion 磁盘缓存文件格式版本，格式或数据模型不兼容地变化时递增，旧版本的缓存会被忽略
+	DiskCacheSchemaVersion = 1
+
+	// 导航目录的缓存key，只有一份
+	navigationCacheKey = "navigation"
+)
+
+// DefaultCacheDir 返回默认的磁盘缓存目录，无法确定用户缓存目录时返回空字符串（不使用磁盘缓存）
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "genshin-starcraft-mcp")
+}
+
+// diskCacheMeta 磁盘缓存文件中除数据以外的字段
+type diskCacheMeta struct {
+	SchemaVersion int       `json:"schema_version"`
+	Kind          string    `json:"kind"` // 页面种类：navigation、tutorial、node_graph
+	Key           string    `json:"key"`
+	BaseURL       string    `json:"base_url"` // 缓存内容所属的站点，站点地址变化后不再使用
+	StoredAt      time.Time `json:"stored_at"`
+	Expires       time.Time `json:"expires"`
+}
+
+// diskCacheEntry 磁盘缓存文件的内容
+type diskCacheEntry struct {
+	diskCacheMeta
+	Data json.RawMessage `json:"data"`
+}
+
+// diskCacheFile 缓存文件的元数据和文件大小
+type diskCacheFile struct {
+	diskCacheMeta
+	Size int64
+}
+
+// valid 判断缓存文件是否属于当前的格式版本和站点
+func (c *diskCache) valid(meta diskCacheMeta) bool {
+	return meta.SchemaVersion == DiskCacheSchemaVersion && meta.BaseURL == c.baseURL
+}
+
+// diskCache 以JSON文件保存解析结果的持久缓存，进程重启后仍可使用。
+// 文件位于 <dir>/v<版本>/<种类>/ 下；为nil时所有操作都是空操作
+type diskCache struct {
+	dir     string
+	baseURL string
+}
+
+// newDiskCache 创建磁盘缓存，dir 为空时返回nil
+func newDiskCache(dir string, baseURL string) *diskCache {
+	if dir == "" {
+		return nil
+	}
+	return &diskCache{
+		dir:     filepath.Join(dir, fmt.Sprintf("v%d", DiskCacheSchemaVersion)),
+		baseURL: baseURL,
+	}
+}
+
+// path 缓存文件路径
+func (c *diskCache) path(kind string, key string) string {
+	return filepath.Join(c.dir, kind, pageFileName(key)+".json")
+}
+
+// load 读取缓存到 v 中并返回写入时间和过期时间，是否仍可使用由调用方根据缓存策略判断
+func (c *diskCache) load(kind string, key string, v any) (diskCacheMeta, bool) {
+	if c == nil {
+		return diskCacheMeta{}, false
+	}
+
+	data, err := os.ReadFile(c.path(kind, key))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.Error("Failed to read disk cache", "kind", kind, "key", key, "error", err)
+		}
+		return diskCacheMeta{}, false
+	}
+
+	var entry diskCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		utils.Error("Failed to parse disk cache", "kind", kind, "key", key, "error", err)
+		return diskCacheMeta{}, false
+	}
+	if !c.valid(entry.diskCacheMeta) || entry.Kind != kind || entry.Key != key {
+		return diskCacheMeta{}, false
+	}
+
+	if err := json.Unmarshal(entry.Data, v); err != nil {
+		utils.Error("Failed to parse disk cache data", "kind", kind, "key", key, "error", err)
+		return diskCacheMeta{}, false
+	}
+
+	utils.Debug("Disk cache hit", "kind", kind, "key", key, "stored_at", entry.StoredAt, "expires", entry.Expires)
+	return entry.diskCacheMeta, true
+}
+
+// list 返回所有属于当前格式版本和站点的缓存条目
+func (c *diskCache) list() []diskCacheFile {
+	if c == nil {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*", "*.json"))
+	if err != nil {
+		return nil
+	}
+
+	var files []diskCacheFile
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var meta diskCacheMeta
+		if err := json.Unmarshal(data, &meta); err != nil || !c.valid(meta) {
+			continue
+		}
+		files = append(files, diskCacheFile{diskCacheMeta: meta, Size: int64(len(data))})
+	}
+	return files
+}
+
+// remove 删除缓存文件，文件存在并已删除时返回true
+func (c *diskCache) remove(kind string, key string) bool {
+	if c == nil {
+		return false
+	}
+
+	if err := os.Remove(c.path(kind, key)); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.Error("Failed to remove disk cache", "kind", kind, "key", key, "error", err)
+		}
+		return false
+	}
+
+	utils.Debug("Removed disk cache", "kind", kind, "key", key)
+	return true
+}
+
+// store 写入缓存，失败只记录日志，不影响正常返回
+func (c *diskCache) store(kind string, key string, v any, expires time.Time) {
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		utils.Error("Failed to encode disk cache data", "kind", kind, "key", key, "error", err)
+		return
+	}
+	entry, err := json.Marshal(diskCacheEntry{
+		diskCacheMeta: diskCacheMeta{
+			SchemaVersion: DiskCacheSchemaVersion,
+			Kind:          kind,
+			Key:           key,
+			BaseURL:       c.baseURL,
+			StoredAt:      time.Now(),
+			Expires:       expires,
+		},
+		Data: data,
+	})
+	if err != nil {
+		utils.Error("Failed to encode disk cache entry", "kind", kind, "key", key, "error", err)
+		return
+	}
+
+	path := c.path(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		utils.Error("Failed to create disk cache directory", "path", path, "error", err)
+		return
+	}
+	if err := writeFileAtomic(path, entry); err != nil {
+		utils.Error("Failed to write disk cache", "kind", kind, "key", key, "error", err)
+		return
+	}
+
+	utils.Debug("Stored disk cache", "kind", kind, "key", key, "bytes", len(entry), "expires", expires)
+}
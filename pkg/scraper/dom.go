@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// 块级元素，输出文本时前后换行，模拟浏览器的innerText
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "tr": true,
+	"ul": true,
+}
+
+// elementText 获取元素的可读文本，块级元素之间保留换行，等同于rod的 element.Text()
+func elementText(sel *goquery.Selection) string {
+	return nodesText(sel.Nodes)
+}
+
+// nodesText 获取节点列表的可读文本
+func nodesText(nodes []*html.Node) string {
+	var sb strings.Builder
+	for _, node := range nodes {
+		writeNodeText(&sb, node)
+	}
+
+	// 清理每行首尾空白并合并多余空行
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(sb.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank && len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		lines = append(lines, line)
+		blank = false
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// writeNodeText 递归写入节点文本
+func writeNodeText(sb *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		sb.WriteString(collapseSpaces(node.Data))
+		return
+	case html.ElementNode:
+		switch node.Data {
+		case "script", "style", "noscript", "template":
+			return
+		case "br":
+			sb.WriteString("\n")
+			return
+		case "td", "th":
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				writeNodeText(sb, child)
+			}
+			sb.WriteString("\t")
+			return
+		}
+	}
+
+	block := node.Type == html.ElementNode && blockElements[node.Data]
+	if block {
+		sb.WriteString("\n")
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeNodeText(sb, child)
+	}
+	if block {
+		sb.WriteString("\n")
+	}
+}
+
+// collapseSpaces 将连续空白合并为单个空格
+func collapseSpaces(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				sb.WriteRune(' ')
+			}
+			space = true
+			continue
+		}
+		sb.WriteRune(r)
+		space = false
+	}
+	return sb.String()
+}
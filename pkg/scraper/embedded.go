@@ -0,0 +1,10 @@
+//go:build embeddata
+
+package scraper
+
+import _ "embed"
+
+// embeddedDataset 由 cmd/gendata 从快照生成的数据集，实时获取失败时作为后备
+//
+//go:embed embedded/dataset.json.gz
+var embeddedDataset []byte
@@ -0,0 +1,6 @@
+//go:build !embeddata
+
+package scraper
+
+// embeddedDataset 未使用 embeddata 标签构建时没有内嵌数据集
+var embeddedDataset []byte
@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// 抓取错误分类，调用方通过 errors.Is 判断错误类型
+var (
+	// ErrNodeNotFound 页面中没有指定名称的节点
+	ErrNodeNotFound = errors.New("node not found")
+
+	// ErrSectionNotFound 教程中没有指定ID或标题的章节
+	ErrSectionNotFound = errors.New("section not found")
+
+	// ErrUnknownNodeType 不支持的客户端类型或节点类型
+	ErrUnknownNodeType = errors.New("unknown node type")
+
+	// ErrSelectorMissing 页面中缺少预期的元素，通常是页面结构发生了变化
+	ErrSelectorMissing = errors.New("selector missing")
+
+	// ErrNavigationTimeout 页面加载超时
+	ErrNavigationTimeout = errors.New("navigation timeout")
+
+	// ErrNavigationFailed 页面导航失败，如连接被拒绝、连接断开或浏览器中的导航出错
+	ErrNavigationFailed = errors.New("navigation failed")
+
+	// ErrBrowserUnavailable 浏览器无法启动、连接已断开或当前获取方式不支持页面交互
+	ErrBrowserUnavailable = errors.New("browser unavailable")
+
+	// ErrSnapshotNotFound 快照目录中没有可用的快照
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+
+	// ErrCacheEntryNotFound 内存和磁盘缓存中都没有指定key的条目
+	ErrCacheEntryNotFound = errors.New("cache entry not found")
+
+	// ErrInvalidCacheKey 缓存条目的种类不是 navigation、tutorial 或 node_graph，或缺少key
+	ErrInvalidCacheKey = errors.New("invalid cache key")
+
+	// ErrNotInSnapshot 离线模式下快照中没有请求的内容
+	ErrNotInSnapshot = errors.New("not in offline snapshot")
+)
+
+// selectorError 构造元素缺失错误；请求已取消时返回取消原因，请求整体超时归为 ErrNavigationTimeout
+func selectorError(ctx context.Context, selector string, url string, err error) error {
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, context.Canceled):
+		return ctxErr
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return fmt.Errorf("%w: waiting for %s on %s: %w", ErrNavigationTimeout, selector, url, ctxErr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s on %s: %w", ErrSelectorMissing, selector, url, err)
+	}
+	return fmt.Errorf("%w: %s on %s", ErrSelectorMissing, selector, url)
+}
+
+// navigationError 构造页面导航错误；请求已取消时返回取消原因，超时归为 ErrNavigationTimeout，其它归为 ErrNavigationFailed
+func navigationError(ctx context.Context, url string, err error) error {
+	if ctxErr := ctx.Err(); errors.Is(ctxErr, context.Canceled) {
+		return ctxErr
+	}
+	if isTimeout(err) {
+		return fmt.Errorf("%w: %s: %w", ErrNavigationTimeout, url, err)
+	}
+	return fmt.Errorf("%w: %s: %w", ErrNavigationFailed, url, err)
+}
+
+// isTimeout 判断错误是否由超时引起
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
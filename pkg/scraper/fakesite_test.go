@@ -0,0 +1,182 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 本地假站点中的页面ID
+const (
+	fakeServerExecID  = "fakeexec0001"
+	fakeServerQueryID = "fakequery001"
+	fakeClientExecID  = "fakecexec001"
+	fakeGuideID       = "fakeguide001"
+)
+
+// fakeNavigationHTML 包含导航菜单的页面，侧边栏结构与官方站点一致
+var fakeNavigationHTML = `<html><body>
+<div class="tw-scrollbar">
+  <div><span>入门</span>
+    <a href="/ys/ugc/tutorial/detail/` + navigationPageID + `">概述</a>
+    <a href="/ys/ugc/tutorial/detail/` + fakeGuideID + `">节点图基础</a>
+  </div>
+  <div><span>节点图</span>
+    <div><span>服务器节点</span>
+      <a href="/ys/ugc/tutorial/detail/` + fakeServerExecID + `">执行节点</a>
+      <a href="/ys/ugc/tutorial/detail/` + fakeServerQueryID + `">查询节点</a>
+    </div>
+    <div><span>客户端节点</span>
+      <a href="/ys/ugc/tutorial/detail/` + fakeClientExecID + `">执行节点</a>
+    </div>
+  </div>
+</div>
+<div class="doc-view"><h1>概述</h1><p>千星奇域综合指南</p></div>
+</body></html>`
+
+// fakeNodePageHTML 生成节点类型页面，每个节点包含描述和参数表格
+func fakeNodePageHTML(title string, nodes ...string) string {
+	var sb strings.Builder
+	sb.WriteString(`<html><head><title>` + title + `</title></head><body><div class="doc-view">`)
+	sb.WriteString(`<h1>` + title + `</h1>`)
+	for i, node := range nodes {
+		fmt.Fprintf(&sb, `<h2>%d. %s</h2><p>节点功能</p><p>%s的说明</p>`, i+1, node, node)
+		sb.WriteString(`<div class="table-wrapper"><table><tbody>`)
+		sb.WriteString(`<tr><td>参数类型</td><td>参数名</td><td>类型</td><td>说明</td></tr>`)
+		sb.WriteString(`<tr><td>入参</td><td>目标实体</td><td>实体</td><td>要操作的实体</td></tr>`)
+		sb.WriteString(`<tr><td>出参</td><td>结果</td><td>布尔值</td><td>是否成功</td></tr>`)
+		sb.WriteString(`</tbody></table></div>`)
+	}
+	sb.WriteString(`</div></body></html>`)
+	return sb.String()
+}
+
+// fakeGuideHTML 普通教程页面
+const fakeGuideHTML = `<html><body><div class="doc-view">
+<h1>节点图基础</h1>
+<p>节点图由<strong>节点</strong>和连线组成。</p>
+<h2>创建节点图</h2>
+<ol><li>打开编辑器</li><li>新建节点图</li></ol>
+<h2>连接节点</h2>
+<p>拖动端口即可连接，详见<a href="/ys/ugc/tutorial/detail/` + fakeServerExecID + `">执行节点</a>。</p>
+</div></body></html>`
+
+// fakeSite 模拟官方教程站点的本地服务器，按页面ID返回固定的HTML，并记录每个页面的请求次数
+type fakeSite struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	pages    map[string]string // 页面ID → HTML
+	hits     map[string]int    // 页面ID → 请求次数
+	failures map[string][]int  // 页面ID → 依次返回的错误状态码，用完后正常返回页面
+	delay    time.Duration     // 每个请求返回前的等待时间，用于制造并发的重叠
+}
+
+// newFakeSite 启动包含导航目录、节点类型页面和教程的假站点，测试结束时关闭
+func newFakeSite(t *testing.T) *fakeSite {
+	t.Helper()
+
+	site := &fakeSite{
+		pages: map[string]string{
+			navigationPageID:  fakeNavigationHTML,
+			fakeServerExecID:  fakeNodePageHTML("服务器执行节点", "设置自定义变量", "销毁实体", "发送信号"),
+			fakeServerQueryID: fakeNodePageHTML("服务器查询节点", "获取自定义变量", "查询实体位置"),
+			fakeClientExecID:  fakeNodePageHTML("客户端执行节点", "播放特效"),
+			fakeGuideID:       fakeGuideHTML,
+		},
+		hits:     make(map[string]int),
+		failures: make(map[string][]int),
+	}
+	site.Server = httptest.NewServer(http.HandlerFunc(site.serve))
+	t.Cleanup(site.Close)
+	return site
+}
+
+// serve 返回页面，页面不存在时返回404
+func (s *fakeSite) serve(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutPrefix(r.URL.Path, tutorialPath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	s.hits[id]++
+	html, found := s.pages[id]
+	status := http.StatusOK
+	if codes := s.failures[id]; len(codes) > 0 {
+		status, s.failures[id] = codes[0], codes[1:]
+	}
+	delay := s.delay
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case status != http.StatusOK:
+		w.WriteHeader(status)
+	case !found:
+		http.NotFound(w, r)
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, html)
+	}
+}
+
+// fail 让页面接下来的请求依次返回指定的状态码
+func (s *fakeSite) fail(id string, codes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[id] = append(s.failures[id], codes...)
+}
+
+// hitCount 返回页面被请求的次数
+func (s *fakeSite) hitCount(id string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[id]
+}
+
+// fastRetryPolicy 测试用的重试策略，退避时间很短
+func fastRetryPolicy(attempts int) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    attempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		Multiplier:     2,
+		Budget:         5 * time.Second,
+	}
+}
+
+// newTestBrowser 创建通过HTTP获取器访问假站点的浏览器，不使用磁盘缓存和后台刷新
+func newTestBrowser(t *testing.T, site *fakeSite, opts Options) *Browser {
+	t.Helper()
+
+	opts.Fetcher = FetcherHTTP
+	opts.BaseURL = site.URL
+	if opts.Retry == nil {
+		opts.Retry = fastRetryPolicy(1)
+	}
+	if opts.Cache == nil {
+		cache := DefaultCachePolicy()
+		cache.RefreshInterval = 0
+		opts.Cache = &cache
+	}
+
+	b, err := NewBrowser(opts)
+	if err != nil {
+		t.Fatalf("NewBrowser: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
@@ -0,0 +1,191 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// 页面获取方式
+const (
+	// FetcherRod 使用rod驱动Chromium获取渲染后的页面
+	FetcherRod = "rod"
+
+	// FetcherHTTP 使用net/http直接获取页面HTML，不依赖Chromium
+	FetcherHTTP = "http"
+)
+
+// Fetcher 页面获取器，返回页面渲染后的HTML
+type Fetcher interface {
+	// Fetch 获取指定URL的页面HTML，ctx取消时应尽快返回
+	Fetch(ctx context.Context, url string) (string, error)
+
+	// Close 释放获取器占用的资源
+	Close() error
+}
+
+// HTTPFetcher 基于net/http的页面获取器，读取服务端输出的 .doc-view 标记
+type HTTPFetcher struct {
+	client    *http.Client
+	userAgent string
+}
+
+// NewHTTPFetcher 创建新的HTTP页面获取器
+func NewHTTPFetcher() *HTTPFetcher {
+	return &HTTPFetcher{
+		client: &http.Client{
+			Timeout: pageLoadTimeout,
+		},
+		userAgent: "Mozilla/5.0 (compatible; genshin-starcraft-mcp)",
+	}
+}
+
+// Fetch 获取指定URL的页面HTML
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (string, error) {
+	utils.Debug("Fetching page over http", "url", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %s: %w", url, err)
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", navigationError(ctx, url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &HTTPStatusError{URL: url, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", navigationError(ctx, url, err)
+	}
+
+	utils.Debug("Fetched page over http", "url", url, "bytes", len(body))
+	return string(body), nil
+}
+
+// HTTPStatusError 页面返回了非200状态码
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("failed to fetch %s: unexpected status %s", e.URL, e.Status)
+}
+
+// Close 释放获取器占用的资源
+func (f *HTTPFetcher) Close() error {
+	f.client.CloseIdleConnections()
+	return nil
+}
+
+// NewFetcher 根据 opts.Fetcher 创建页面获取器，页面池和Chromium配置仅对rod获取器生效
+func NewFetcher(opts Options) (Fetcher, error) {
+	switch opts.Fetcher {
+	case "", FetcherRod:
+		return NewRodFetcher(opts.MaxPages, opts.Chrome, opts.HealthInterval)
+	case FetcherHTTP:
+		return NewHTTPFetcher(), nil
+	default:
+		return nil, fmt.Errorf("unknown fetcher %q, supported: %s, %s", opts.Fetcher, FetcherRod, FetcherHTTP)
+	}
+}
+
+// newFetcher 根据浏览器配置创建页面获取器：回放模式直接读取fixtures，录制模式包装实际的获取器
+func newFetcher(opts Options) (Fetcher, error) {
+	if opts.Offline {
+		fetcher, err := newOfflineFetcher(opts.SnapshotDir, opts.OfflineSnapshot)
+		if err != nil {
+			return nil, err
+		}
+		utils.Info("Serving from offline snapshot", "version", fetcher.snapshot.Manifest.Version, "dir", fetcher.snapshot.Dir)
+		return fetcher, nil
+	}
+
+	if opts.ReplayDir != "" {
+		utils.Info("Replaying pages from fixtures", "dir", opts.ReplayDir)
+		return NewReplayFetcher(opts.ReplayDir)
+	}
+
+	fetcher, err := NewFetcher(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.RecordDir != "" {
+		recorder, err := NewRecordingFetcher(fetcher, opts.RecordDir)
+		if err != nil {
+			fetcher.Close()
+			return nil, err
+		}
+		utils.Info("Recording pages to fixtures", "dir", opts.RecordDir)
+		return recorder, nil
+	}
+
+	return fetcher, nil
+}
+
+// rodFetcherOf 查找获取器链中的rod获取器，不存在时返回nil
+func rodFetcherOf(fetcher Fetcher) *RodFetcher {
+	for fetcher != nil {
+		switch f := fetcher.(type) {
+		case *RodFetcher:
+			return f
+		case interface{ Unwrap() Fetcher }:
+			fetcher = f.Unwrap()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// fetchDocument 获取页面并解析为DOM文档，页面缺少任一 required 选择器时视为未加载完成，按重试策略重新获取
+func (b *Browser) fetchDocument(ctx context.Context, url string, required ...string) (*goquery.Document, error) {
+	start := time.Now()
+
+	var doc *goquery.Document
+	err := b.retry.Do(ctx, "fetch "+url, func() error {
+		if err := waitRequestLimiter(ctx); err != nil {
+			return err
+		}
+		html, err := b.fetcher.Fetch(ctx, url)
+		if err != nil {
+			return err
+		}
+
+		parsed, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+		if err != nil {
+			return fmt.Errorf("failed to parse html of %s: %w", url, err)
+		}
+
+		for _, selector := range required {
+			if parsed.Find(selector).Length() == 0 {
+				return selectorError(ctx, selector, url, nil)
+			}
+		}
+
+		doc = parsed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.Debug("Fetched document", "url", url, "elapsed", time.Since(start))
+	return doc, nil
+}
@@ -0,0 +1,140 @@
+package scraper
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// 页面ID中允许作为文件名的字符
+var fixtureNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// RecordingFetcher 录制模式：透传给内部获取器，并将每个页面渲染后的DOM保存到fixtures目录
+type RecordingFetcher struct {
+	inner Fetcher
+	dir   string
+}
+
+// NewRecordingFetcher 创建录制模式的页面获取器
+func NewRecordingFetcher(inner Fetcher, dir string) (*RecordingFetcher, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fixtures dir %s: %w", dir, err)
+	}
+	return &RecordingFetcher{
+		inner: inner,
+		dir:   dir,
+	}, nil
+}
+
+// Fetch 获取页面并保存为fixture
+func (f *RecordingFetcher) Fetch(ctx context.Context, url string) (string, error) {
+	html, err := f.inner.Fetch(ctx, url)
+	if err != nil {
+		return "", err
+	}
+
+	path := fixturePath(f.dir, url)
+	if err := writeFileAtomic(path, []byte(html)); err != nil {
+		// 录制失败不影响正常返回
+		utils.Error("Failed to record fixture", "url", url, "path", path, "error", err)
+	} else {
+		utils.Debug("Recorded fixture", "url", url, "path", path, "bytes", len(html))
+	}
+
+	return html, nil
+}
+
+// Close 释放内部获取器的资源
+func (f *RecordingFetcher) Close() error {
+	return f.inner.Close()
+}
+
+// Unwrap 返回被包装的获取器
+func (f *RecordingFetcher) Unwrap() Fetcher {
+	return f.inner
+}
+
+// ReplayFetcher 回放模式：从fixtures目录读取页面，不访问网络
+type ReplayFetcher struct {
+	dir string
+}
+
+// NewReplayFetcher 创建回放模式的页面获取器
+func NewReplayFetcher(dir string) (*ReplayFetcher, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fixtures dir %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("fixtures path %s is not a directory", dir)
+	}
+	return &ReplayFetcher{
+		dir: dir,
+	}, nil
+}
+
+// Fetch 读取页面对应的fixture
+func (f *ReplayFetcher) Fetch(ctx context.Context, url string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	path := fixturePath(f.dir, url)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("no recorded fixture for %s: %w", url, err)
+	}
+
+	utils.Debug("Replayed fixture", "url", url, "path", path, "bytes", len(data))
+	return string(data), nil
+}
+
+// Close 回放模式无需释放资源
+func (f *ReplayFetcher) Close() error {
+	return nil
+}
+
+// fixturePath 根据URL生成fixture文件路径，使用页面ID命名，与站点地址无关
+func fixturePath(dir string, url string) string {
+	return filepath.Join(dir, pageFileName(url)+".html")
+}
+
+// pageFileName 根据URL或页面ID生成不含扩展名的文件名，ID中有不能用作文件名的字符时使用哈希
+func pageFileName(url string) string {
+	name := url[strings.LastIndex(url, "/")+1:]
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+
+	if !fixtureNameRegex.MatchString(name) {
+		sum := sha1.Sum([]byte(url))
+		name = hex.EncodeToString(sum[:])
+	}
+
+	return name
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免中断时留下不完整的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,81 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// flightGroup 合并对同一key的并发获取：同时只执行一次获取，其余调用等待并共享结果或错误
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[T]
+}
+
+// flightCall 正在进行的一次获取
+type flightCall[T any] struct {
+	done    chan struct{} // 获取完成后关闭
+	cancel  context.CancelFunc
+	waiters int // 仍在等待结果的调用数，降为0时取消获取
+	val     T
+	err     error
+}
+
+// do 执行或加入key对应的获取。获取不绑定到单个调用的ctx：某个调用取消时只有它自己提前返回，
+// 所有调用都取消后才取消获取
+func (g *flightGroup[T]) do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[T])
+	}
+	call, ok := g.calls[key]
+	if ok {
+		utils.Debug("Joining in-flight fetch", "key", key, "waiters", call.waiters)
+	} else {
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &flightCall[T]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go g.run(fetchCtx, key, call, fn)
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.val, call.err
+	case <-ctx.Done():
+		g.leave(key, call)
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// run 执行获取并通知所有等待的调用
+func (g *flightGroup[T]) run(ctx context.Context, key string, call *flightCall[T], fn func(ctx context.Context) (T, error)) {
+	defer call.cancel()
+
+	call.val, call.err = fn(ctx)
+
+	g.mu.Lock()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(call.done)
+}
+
+// leave 取消一个调用的等待，没有调用等待时取消获取，之后的调用重新开始获取
+func (g *flightGroup[T]) leave(key string, call *flightCall[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	call.cancel()
+}
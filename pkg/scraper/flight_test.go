@@ -0,0 +1,179 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingFetcher 统计获取次数的假获取器，每次获取阻塞到 release 关闭，并记录获取结束时ctx是否已取消
+type countingFetcher struct {
+	html     string
+	release  chan struct{}
+	fetches  atomic.Int32
+	canceled atomic.Int32
+}
+
+func newCountingFetcher(html string) *countingFetcher {
+	return &countingFetcher{html: html, release: make(chan struct{})}
+}
+
+// Fetch 等待放行后返回页面，ctx取消时立即返回
+func (f *countingFetcher) Fetch(ctx context.Context, url string) (string, error) {
+	f.fetches.Add(1)
+	select {
+	case <-f.release:
+		return f.html, nil
+	case <-ctx.Done():
+		f.canceled.Add(1)
+		return "", ctx.Err()
+	}
+}
+
+// Close 没有需要释放的资源
+func (f *countingFetcher) Close() error {
+	return nil
+}
+
+// newFlightTestBrowser 创建使用假获取器的浏览器，不使用磁盘缓存、后台刷新和重试
+func newFlightTestBrowser(t *testing.T, fetcher Fetcher) *Browser {
+	t.Helper()
+
+	b := newTestBrowser(t, newFakeSite(t), Options{})
+	b.fetcher = fetcher
+	return b
+}
+
+// waitForWaiters 等待到有 n 个调用在等待key对应的获取
+func waitForWaiters[T any](t *testing.T, g *flightGroup[T], key string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		call := g.calls[key]
+		waiters := 0
+		if call != nil {
+			waiters = call.waiters
+		}
+		g.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d callers on %s", n, key)
+}
+
+// TestGetTutorialSharesFetch 同一教程的并发获取只获取一次页面，所有调用共享结果
+func TestGetTutorialSharesFetch(t *testing.T) {
+	fetcher := newCountingFetcher(fakeGuideHTML)
+	b := newFlightTestBrowser(t, fetcher)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tutorial, err := b.GetTutorial(context.Background(), fakeGuideID)
+			if err == nil && tutorial.Title != "节点图基础" {
+				t.Errorf("GetTutorial returned title %q", tutorial.Title)
+			}
+			errs <- err
+		}()
+	}
+
+	waitForWaiters(t, &b.tutorialFlight, fakeGuideID, callers)
+	close(fetcher.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if fetches := fetcher.fetches.Load(); fetches != 1 {
+		t.Errorf("page fetched %d times, want 1", fetches)
+	}
+}
+
+// TestGetTutorialCancelledCallerKeepsFetch 一个调用取消时只有它自己返回，共享的获取继续为其它调用完成
+func TestGetTutorialCancelledCallerKeepsFetch(t *testing.T) {
+	fetcher := newCountingFetcher(fakeGuideHTML)
+	b := newFlightTestBrowser(t, fetcher)
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := b.GetTutorial(cancelCtx, fakeGuideID)
+		cancelled <- err
+	}()
+	waitForWaiters(t, &b.tutorialFlight, fakeGuideID, 1)
+
+	type result struct {
+		title string
+		err   error
+	}
+	kept := make(chan result, 1)
+	go func() {
+		tutorial, err := b.GetTutorial(context.Background(), fakeGuideID)
+		if err != nil {
+			kept <- result{err: err}
+			return
+		}
+		kept <- result{title: tutorial.Title}
+	}()
+	waitForWaiters(t, &b.tutorialFlight, fakeGuideID, 2)
+
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller returned %v, want context.Canceled", err)
+	}
+	waitForWaiters(t, &b.tutorialFlight, fakeGuideID, 1)
+
+	close(fetcher.release)
+	got := <-kept
+	if got.err != nil || got.title != "节点图基础" {
+		t.Errorf("remaining caller got %q, %v", got.title, got.err)
+	}
+	if fetches := fetcher.fetches.Load(); fetches != 1 {
+		t.Errorf("page fetched %d times, want 1", fetches)
+	}
+	if n := fetcher.canceled.Load(); n != 0 {
+		t.Errorf("shared fetch was cancelled %d times", n)
+	}
+}
+
+// TestGetTutorialAllCallersCancelled 所有调用都取消后取消获取
+func TestGetTutorialAllCallersCancelled(t *testing.T) {
+	fetcher := newCountingFetcher(fakeGuideHTML)
+	b := newFlightTestBrowser(t, fetcher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.GetTutorial(ctx, fakeGuideID)
+		done <- err
+	}()
+	waitForWaiters(t, &b.tutorialFlight, fakeGuideID, 1)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("GetTutorial returned %v, want context.Canceled", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for fetcher.canceled.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("fetch was not cancelled after every caller left")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
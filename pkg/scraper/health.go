@@ -0,0 +1,309 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"genshin-starcraft-mcp/pkg/models"
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// 解析结果的不变量，超出范围说明页面结构可能已变化
+const (
+	// 单个节点类型页面的节点数量范围
+	nodePageMinNodes = 1
+	nodePageMaxNodes = 500
+
+	// 导航目录中至少应有的页面数
+	navigationMinPages = 5
+
+	// 违规信息中最多列出的节点名称数
+	maxViolationSamples = 5
+)
+
+// 导航目录在健康报告中的名称
+const navigationHealthPage = "navigation"
+
+// 节点参数表格的表头
+var paramTableHeaders = []string{"参数类型", "参数名", "类型", "说明"}
+
+// 页面种类
+const (
+	PageKindNavigation = "navigation"
+	PageKindTutorial   = "tutorial"
+	PageKindNodeGraph  = "node_graph"
+)
+
+// Violation 解析结果违反的不变量
+type Violation struct {
+	Rule    string `json:"rule"`    // 规则名，如 node_count、table_headers
+	Message string `json:"message"` // 问题说明
+}
+
+// PageHealth 单个页面最近一次解析的检查结果
+type PageHealth struct {
+	Page       string      `json:"page"` // 页面名称，如 navigation、服务器节点/执行节点
+	Kind       string      `json:"kind"`
+	URL        string      `json:"url"`
+	CheckedAt  time.Time   `json:"checked_at"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// HealthReport 解析器健康报告
+type HealthReport struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Pages       []PageHealth `json:"pages"`
+}
+
+// Healthy 没有任何违规时返回true
+func (r *HealthReport) Healthy() bool {
+	return r.ViolationCount() == 0
+}
+
+// ViolationCount 返回违规总数
+func (r *HealthReport) ViolationCount() int {
+	count := 0
+	for _, page := range r.Pages {
+		count += len(page.Violations)
+	}
+	return count
+}
+
+// Markdown 将报告格式化为Markdown
+func (r *HealthReport) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# 解析器健康检查\n\n")
+
+	if len(r.Pages) == 0 {
+		sb.WriteString("尚未解析任何页面，请先调用其它工具或立即运行检查\n")
+		return sb.String()
+	}
+
+	if r.Healthy() {
+		sb.WriteString(fmt.Sprintf("状态: 正常，已检查 %d 个页面\n\n", len(r.Pages)))
+	} else {
+		sb.WriteString(fmt.Sprintf("状态: 发现 %d 个问题，官方页面结构可能已变化\n\n", r.ViolationCount()))
+	}
+
+	sb.WriteString("| 页面 | 检查时间 | 问题数 |\n")
+	sb.WriteString("|------|----------|--------|\n")
+	for _, page := range r.Pages {
+		sb.WriteString(fmt.Sprintf("| %s | %s | %d |\n", page.Page, page.CheckedAt.Format(time.DateTime), len(page.Violations)))
+	}
+
+	for _, page := range r.Pages {
+		if len(page.Violations) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n## %s\n\n%s\n\n", page.Page, page.URL))
+		for _, violation := range page.Violations {
+			sb.WriteString(fmt.Sprintf("- `%s`: %s\n", violation.Rule, violation.Message))
+		}
+	}
+
+	return sb.String()
+}
+
+// healthRecorder 记录每个页面最近一次解析的检查结果
+type healthRecorder struct {
+	mu    sync.Mutex
+	pages map[string]PageHealth
+}
+
+// record 记录页面检查结果，违规写入错误日志
+func (h *healthRecorder) record(page PageHealth) {
+	for _, violation := range page.Violations {
+		utils.Error("Parser invariant violated", "page", page.Page, "url", page.URL, "rule", violation.Rule, "message", violation.Message)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pages == nil {
+		h.pages = make(map[string]PageHealth)
+	}
+	h.pages[page.Page] = page
+}
+
+// report 按页面名称排序生成报告
+func (h *healthRecorder) report() *HealthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	report := &HealthReport{GeneratedAt: time.Now(), Pages: []PageHealth{}}
+	for _, page := range h.pages {
+		report.Pages = append(report.Pages, page)
+	}
+	slices.SortFunc(report.Pages, func(a, b PageHealth) int {
+		return strings.Compare(a.Page, b.Page)
+	})
+	return report
+}
+
+// HealthReport 返回最近解析过的各页面的检查结果
+func (b *Browser) HealthReport() *HealthReport {
+	return b.health.report()
+}
+
+// CheckParserHealth 立即重新抓取导航目录和所有节点类型页面并检查，不使用缓存，结果同时更新缓存。
+// 单个页面获取失败记为该页面的违规，不中断检查
+func (b *Browser) CheckParserHealth(ctx context.Context) (*HealthReport, error) {
+	utils.Info("Running parser health check")
+
+	if _, err := b.fetchNavigation(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		b.recordFetchFailure(navigationHealthPage, PageKindNavigation, b.TutorialURL(navigationPageID), err)
+	}
+
+	nodeTypes := b.NodeTypes(ctx)
+	for _, clientType := range nodeTypes.ClientTypes() {
+		for _, nodeType := range nodeTypes.Types(clientType) {
+			if _, err := b.loadNodeGraphPage(ctx, clientType, nodeType, nodeTypes[clientType][nodeType]); err != nil {
+				if errors.Is(err, context.Canceled) {
+					return nil, err
+				}
+				id := nodeTypes[clientType][nodeType]
+				b.recordFetchFailure(nodeGraphPageName(clientType, nodeType), PageKindNodeGraph, b.TutorialURL(id), err)
+			}
+		}
+	}
+
+	report := b.HealthReport()
+	utils.Info("Parser health check completed", "pages", len(report.Pages), "violations", report.ViolationCount())
+	return report, nil
+}
+
+// recordFetchFailure 将页面获取失败记为违规
+func (b *Browser) recordFetchFailure(page string, kind string, url string, err error) {
+	b.health.record(PageHealth{
+		Page:       page,
+		Kind:       kind,
+		URL:        url,
+		CheckedAt:  time.Now(),
+		Violations: []Violation{{Rule: "fetch", Message: err.Error()}},
+	})
+}
+
+// nodeGraphPageName 节点类型页面在健康报告中的名称
+func nodeGraphPageName(clientType string, nodeType string) string {
+	return clientType + "/" + nodeType
+}
+
+// checkNavigation 检查导航目录：页面数量和节点类型页面能否识别
+func checkNavigation(items []models.NavigationItem) []Violation {
+	var violations []Violation
+
+	if pages := len(FlattenNavigation(items)); pages < navigationMinPages {
+		violations = append(violations, Violation{
+			Rule:    "navigation_pages",
+			Message: fmt.Sprintf("导航目录只解析出 %d 个页面，预期至少 %d 个", pages, navigationMinPages),
+		})
+	}
+
+	if len(nodeTypesFromNavigation(items)) == 0 {
+		violations = append(violations, Violation{
+			Rule:    "node_type_discovery",
+			Message: "导航目录中未识别出任何节点类型页面，将只能使用内置映射",
+		})
+	}
+
+	return violations
+}
+
+// checkTutorial 检查教程：正文和章节不能为空
+func checkTutorial(tutorial *models.Tutorial) []Violation {
+	var violations []Violation
+
+	if strings.TrimSpace(tutorial.Content) == "" {
+		violations = append(violations, Violation{Rule: "tutorial_content", Message: "教程正文为空"})
+	}
+	if len(tutorial.Sections) == 0 {
+		violations = append(violations, Violation{Rule: "tutorial_sections", Message: "教程中没有解析出任何章节"})
+	}
+
+	return violations
+}
+
+// checkNodeGraphPage 检查节点类型页面：节点数量、参数表格及其表头、参数数量、参数类型列和节点描述
+func checkNodeGraphPage(doc *goquery.Document, page *models.NodeGraphPage) []Violation {
+	var violations []Violation
+
+	if count := len(page.Nodes); count < nodePageMinNodes || count > nodePageMaxNodes {
+		violations = append(violations, Violation{
+			Rule:    "node_count",
+			Message: fmt.Sprintf("解析出 %d 个节点，预期 %d-%d 个", count, nodePageMinNodes, nodePageMaxNodes),
+		})
+	}
+
+	// 参数表格的首行应为固定的四列表头
+	tables := doc.Find("div.table-wrapper table")
+	for i, table := range tables.EachIter() {
+		var headers []string
+		for _, cell := range table.Find("tr").First().Find("td, th").EachIter() {
+			headers = append(headers, elementText(cell))
+		}
+		if !slices.Equal(headers, paramTableHeaders) {
+			violations = append(violations, Violation{
+				Rule:    "table_headers",
+				Message: fmt.Sprintf("第 %d 个参数表格的表头为 %v，预期 %v", i+1, headers, paramTableHeaders),
+			})
+			break
+		}
+	}
+
+	var noDescription, otherParams []string
+	params := 0
+	for _, node := range page.Nodes {
+		if strings.TrimSpace(node.Description) == "" {
+			noDescription = append(noDescription, node.NodeName)
+		}
+		if len(node.Parameters) > 0 {
+			otherParams = append(otherParams, node.NodeName)
+		}
+		params += len(node.Inputs) + len(node.Outputs) + len(node.Parameters)
+	}
+
+	// 参数表格的容器改名或去掉时表格和参数都为0，节点仍能解析出来，需要单独检查
+	if len(page.Nodes) > 0 && tables.Length() == 0 {
+		violations = append(violations, Violation{
+			Rule:    "param_tables",
+			Message: fmt.Sprintf("解析出 %d 个节点，但页面中没有 div.table-wrapper table 参数表格", len(page.Nodes)),
+		})
+	}
+	if len(page.Nodes) > 0 && params == 0 {
+		violations = append(violations, Violation{
+			Rule:    "node_params",
+			Message: fmt.Sprintf("%d 个节点都没有解析出参数，页面有 %d 个参数表格", len(page.Nodes), tables.Length()),
+		})
+	}
+	if len(noDescription) > 0 {
+		violations = append(violations, Violation{
+			Rule:    "node_description",
+			Message: fmt.Sprintf("%d 个节点没有描述: %s", len(noDescription), sampleNames(noDescription)),
+		})
+	}
+	if len(otherParams) > 0 {
+		violations = append(violations, Violation{
+			Rule:    "param_kind",
+			Message: fmt.Sprintf("%d 个节点的参数类型列不是入参/出参: %s", len(otherParams), sampleNames(otherParams)),
+		})
+	}
+
+	return violations
+}
+
+// sampleNames 列出前几个名称
+func sampleNames(names []string) string {
+	if len(names) <= maxViolationSamples {
+		return strings.Join(names, "、")
+	}
+	return strings.Join(names[:maxViolationSamples], "、") + " 等"
+}
@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestCheckNodeGraphPageTables 参数表格的容器变化后，节点仍能解析出来但没有参数，检查应报告违规
+func TestCheckNodeGraphPageTables(t *testing.T) {
+	wrapped := fakeNodePageHTML("服务器执行节点", "设置自定义变量", "销毁实体")
+
+	tests := []struct {
+		name      string
+		html      string
+		wantRules []string
+	}{
+		{name: "wrapped tables", html: wrapped},
+		{
+			name:      "unwrapped tables",
+			html:      strings.ReplaceAll(wrapped, `<div class="table-wrapper">`, `<div class="param-table">`),
+			wantRules: []string{"param_tables", "node_params"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := newFakeSite(t)
+			site.pages[fakeServerExecID] = tt.html
+			b := newTestBrowser(t, site, Options{})
+
+			graphs, err := b.GetNodeGraphs(context.Background(), "服务器节点", "执行节点")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(graphs) != 2 {
+				t.Fatalf("GetNodeGraphs returned %d nodes, want 2", len(graphs))
+			}
+
+			var rules []string
+			for _, page := range b.HealthReport().Pages {
+				if page.Page != nodeGraphPageName("服务器节点", "执行节点") {
+					continue
+				}
+				for _, violation := range page.Violations {
+					rules = append(rules, violation.Rule)
+				}
+			}
+			if !slices.Equal(rules, tt.wantRules) {
+				t.Errorf("violations = %v, want %v", rules, tt.wantRules)
+			}
+		})
+	}
+}
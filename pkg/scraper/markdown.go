@@ -0,0 +1,503 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// 行内换行占位符，合并空白后再替换为Markdown换行
+const lineBreakMark = "\x00"
+
+// markdownEscaper 转义正文文本中会被解析为Markdown行内语法的字符
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"~", `\~`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	"|", `\|`,
+)
+
+// 位于行首时会被解析为标题、列表、引用或分隔线的文本
+var blockMarkerRegex = regexp.MustCompile(`^(#{1,6}(\s|$)|[-+](\s|$)|>|\d{1,9}[.)](\s|$)|[-=]+\s*$)`)
+
+// markdownConverter 将教程正文的DOM转换为保留结构的Markdown
+type markdownConverter struct {
+	base *url.URL // 用于将相对链接转换为绝对链接
+}
+
+// newMarkdownConverter 创建Markdown转换器，baseURL 为相对链接的基准地址
+func newMarkdownConverter(baseURL string) *markdownConverter {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		base = nil
+	}
+	return &markdownConverter{base: base}
+}
+
+// Convert 将选中元素的内容转换为Markdown
+func (c *markdownConverter) Convert(sel *goquery.Selection) string {
+	var blocks []string
+	for _, node := range sel.Nodes {
+		blocks = append(blocks, c.renderBlocks(node)...)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// renderBlocks 渲染节点的所有子节点
+func (c *markdownConverter) renderBlocks(node *html.Node) []string {
+	var children []*html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		children = append(children, child)
+	}
+	return c.renderNodes(children)
+}
+
+// renderNodes 依次渲染同级节点，连续的行内内容合并为一个段落
+func (c *markdownConverter) renderNodes(nodes []*html.Node) []string {
+	var blocks []string
+	var inline strings.Builder
+
+	flush := func() {
+		if text := escapeLineStarts(finishInline(inline.String())); text != "" {
+			blocks = append(blocks, text)
+		}
+		inline.Reset()
+	}
+
+	for _, child := range nodes {
+		if isBlockNode(child) {
+			flush()
+			blocks = append(blocks, c.renderBlock(child)...)
+			continue
+		}
+		inline.WriteString(c.renderInline(child))
+	}
+	flush()
+
+	return blocks
+}
+
+// renderBlock 渲染块级元素
+func (c *markdownConverter) renderBlock(node *html.Node) []string {
+	switch node.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := finishInline(c.renderChildrenInline(node))
+		if text == "" {
+			return nil
+		}
+		level := int(node.Data[1] - '0')
+		return []string{strings.Repeat("#", level) + " " + strings.ReplaceAll(text, "  \n", " ")}
+	case "ul", "ol":
+		if list := c.renderList(node); list != "" {
+			return []string{list}
+		}
+		return nil
+	case "table":
+		if table := c.renderTable(node); table != "" {
+			return []string{table}
+		}
+		return nil
+	case "pre":
+		code := strings.Trim(rawText(node), "\n")
+		if code == "" {
+			return nil
+		}
+		return []string{"```\n" + code + "\n```"}
+	case "blockquote":
+		inner := strings.Join(c.renderBlocks(node), "\n\n")
+		if inner == "" {
+			return nil
+		}
+		return []string{prefixLines(inner, "> ", "> ")}
+	case "hr":
+		return []string{"---"}
+	default:
+		return c.renderBlocks(node)
+	}
+}
+
+// renderList 渲染有序或无序列表，嵌套列表和多段内容按列表标记宽度缩进
+func (c *markdownConverter) renderList(node *html.Node) string {
+	ordered := node.Data == "ol"
+	number := 1
+	if start, err := strconv.Atoi(attr(node, "start")); err == nil {
+		number = start
+	}
+
+	var items []string
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "li" {
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		content := strings.Join(c.renderBlocks(child), "\n")
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// renderTable 渲染表格，合并单元格展开为重复内容
+func (c *markdownConverter) renderTable(node *html.Node) string {
+	grid := tableGrid(node, c.renderCell)
+	if len(grid) == 0 {
+		return ""
+	}
+
+	width := 0
+	for _, cells := range grid {
+		width = max(width, len(cells))
+	}
+	if width == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if caption := findChild(node, "caption"); caption != nil {
+		if text := finishInline(c.renderChildrenInline(caption)); text != "" {
+			sb.WriteString("**" + text + "**\n\n")
+		}
+	}
+
+	// Markdown表格必须有表头，没有th时使用首行作为表头
+	writeTableRow(&sb, grid[0], width)
+	sb.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+	for _, cells := range grid[1:] {
+		writeTableRow(&sb, cells, width)
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// renderCell 渲染表格单元格，单元格内的换行和块级内容使用 <br> 连接
+func (c *markdownConverter) renderCell(cell *html.Node) string {
+	blocks := c.renderBlocks(cell)
+	text := strings.Join(blocks, "<br>")
+	text = strings.ReplaceAll(text, "  \n", "<br>")
+	text = strings.ReplaceAll(text, "\n", "<br>")
+	return escapeTablePipes(text)
+}
+
+// renderChildrenInline 将子节点渲染为行内内容
+func (c *markdownConverter) renderChildrenInline(node *html.Node) string {
+	var sb strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(c.renderInline(child))
+	}
+	return sb.String()
+}
+
+// renderInline 渲染行内节点：强调、行内代码、链接和图片
+func (c *markdownConverter) renderInline(node *html.Node) string {
+	switch node.Type {
+	case html.TextNode:
+		return escapeMarkdown(collapseSpaces(node.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch node.Data {
+	case "script", "style", "noscript", "template":
+		return ""
+	case "br":
+		return lineBreakMark
+	case "strong", "b":
+		return wrapInline(c.renderChildrenInline(node), "**")
+	case "em", "i":
+		return wrapInline(c.renderChildrenInline(node), "*")
+	case "del", "s":
+		return wrapInline(c.renderChildrenInline(node), "~~")
+	case "code":
+		text := strings.TrimSpace(collapseSpaces(rawText(node)))
+		if text == "" {
+			return ""
+		}
+		fence := "`"
+		if strings.Contains(text, "`") {
+			fence = "``"
+		}
+		return fence + text + fence
+	case "a":
+		text := strings.TrimSpace(c.renderChildrenInline(node))
+		href := c.resolve(attr(node, "href"))
+		if href == "" || strings.HasPrefix(href, "javascript:") {
+			return text
+		}
+		if text == "" {
+			text = href
+		}
+		return "[" + text + "](" + escapeLinkDestination(href) + ")"
+	case "img":
+		src := c.resolve(attr(node, "src"))
+		if src == "" {
+			return ""
+		}
+		return "![" + escapeMarkdown(attr(node, "alt")) + "](" + escapeLinkDestination(src) + ")"
+	default:
+		return c.renderChildrenInline(node)
+	}
+}
+
+// resolve 将相对链接转换为绝对链接
+func (c *markdownConverter) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || c.base == nil || strings.HasPrefix(href, "#") {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return c.base.ResolveReference(ref).String()
+}
+
+// linkDestinationEscaper 转义链接地址中会提前结束Markdown链接的字符
+var linkDestinationEscaper = strings.NewReplacer(
+	" ", "%20",
+	"(", "%28",
+	")", "%29",
+)
+
+// escapeLinkDestination 百分号编码链接地址中的空格和括号，地址写入 [text](href) 后不会被截断
+func escapeLinkDestination(href string) string {
+	return linkDestinationEscaper.Replace(href)
+}
+
+// isBlockNode 判断节点是否按块级元素渲染
+func isBlockNode(node *html.Node) bool {
+	return node.Type == html.ElementNode && blockElements[node.Data]
+}
+
+// finishInline 合并行内内容的空白，并将换行占位符替换为Markdown换行
+func finishInline(text string) string {
+	text = collapseSpaces(text)
+	lines := strings.Split(text, lineBreakMark)
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.TrimSpace(strings.Join(lines, "  \n"))
+}
+
+// escapeMarkdown 转义文本中的Markdown行内标记，代码和代码块中的文本不需要转义
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// escapeLineStarts 转义段落中每一行行首的块级标记
+func escapeLineStarts(text string) string {
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = escapeLineStart(lines[i])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeLineStart 转义行首会被解析为块级元素的标记，如 # 标题、- 列表、1. 有序列表和 > 引用
+func escapeLineStart(line string) string {
+	if !blockMarkerRegex.MatchString(line) {
+		return line
+	}
+	// 有序列表标记转义数字后的标点
+	if i := strings.IndexAny(line, ".)"); line[0] >= '0' && line[0] <= '9' && i > 0 {
+		return line[:i] + `\` + line[i:]
+	}
+	return `\` + line
+}
+
+// escapeTablePipes 转义单元格中还未转义的 |（如行内代码和链接地址中的），避免截断单元格
+func escapeTablePipes(text string) string {
+	var sb strings.Builder
+	backslashes := 0
+	for _, r := range text {
+		if r == '|' && backslashes%2 == 0 {
+			sb.WriteByte('\\')
+		}
+		if r == '\\' {
+			backslashes++
+		} else {
+			backslashes = 0
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// wrapInline 用标记包裹行内内容，标记放在首尾空白以内
+func wrapInline(text string, mark string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+	return leading + mark + trimmed + mark + trailing
+}
+
+// prefixLines 为首行和后续行分别添加前缀，空行不添加缩进
+func prefixLines(text string, first string, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line == "":
+			lines[i] = strings.TrimRight(rest, " ")
+		default:
+			lines[i] = rest + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeTableRow 写入一行表格，不足的列补空
+func writeTableRow(sb *strings.Builder, cells []string, width int) {
+	sb.WriteString("|")
+	for i := 0; i < width; i++ {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		sb.WriteString(" " + cell + " |")
+	}
+	sb.WriteString("\n")
+}
+
+// tableGrid 将表格展开为二维网格，合并单元格（rowspan/colspan）在其跨越的每个位置重复出现，
+// cellText 决定单元格内容的渲染方式
+func tableGrid(node *html.Node, cellText func(*html.Node) string) [][]string {
+	type span struct {
+		text      string
+		remaining int
+	}
+
+	var grid [][]string
+	spans := map[int]*span{} // 被上方单元格跨越的列
+
+	for _, row := range tableRows(node) {
+		var cells []string
+		col := 0
+
+		// 填充当前列起被上方单元格跨越的位置
+		fillSpans := func() {
+			for {
+				s, ok := spans[col]
+				if !ok {
+					return
+				}
+				cells = append(cells, s.text)
+				if s.remaining--; s.remaining == 0 {
+					delete(spans, col)
+				}
+				col++
+			}
+		}
+
+		for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+				continue
+			}
+
+			fillSpans()
+
+			text := cellText(cell)
+			colspan := spanAttr(cell, "colspan")
+			rowspan := spanAttr(cell, "rowspan")
+			for k := 0; k < colspan; k++ {
+				cells = append(cells, text)
+				if rowspan > 1 {
+					spans[col] = &span{text: text, remaining: rowspan - 1}
+				}
+				col++
+			}
+		}
+
+		// 行尾仍被上方单元格跨越的列
+		fillSpans()
+
+		grid = append(grid, cells)
+	}
+
+	return grid
+}
+
+// tableRows 按文档顺序返回表格自身的行，不包含嵌套表格中的行
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	for child := table.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.Data {
+		case "tr":
+			rows = append(rows, child)
+		case "thead", "tbody", "tfoot":
+			for row := child.FirstChild; row != nil; row = row.NextSibling {
+				if row.Type == html.ElementNode && row.Data == "tr" {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// findChild 查找指定标签的直接子元素
+func findChild(node *html.Node, tag string) *html.Node {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == tag {
+			return child
+		}
+	}
+	return nil
+}
+
+// attr 读取元素属性
+func attr(node *html.Node, name string) string {
+	for _, a := range node.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// spanAttr 读取 rowspan/colspan，缺省或非法时为1
+func spanAttr(node *html.Node, name string) int {
+	n, err := strconv.Atoi(attr(node, name))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// rawText 获取节点的原始文本，保留空白，用于代码块
+func rawText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	if node.Type == html.ElementNode && node.Data == "br" {
+		return "\n"
+	}
+	var sb strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(rawText(child))
+	}
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package scraper
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var update = flag.Bool("update", false, "用当前输出更新golden文件")
+
+// TestMarkdownGolden 将 testdata/markdown 下保存的教程HTML转换为Markdown，与同名的 .md golden文件比较。
+// 修改转换规则后使用 go test -run TestMarkdownGolden -update 更新golden文件
+func TestMarkdownGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "markdown", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no golden inputs found")
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".html")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := doc.Find(".doc-view").First()
+			if content.Length() == 0 {
+				t.Fatalf("%s has no .doc-view", file)
+			}
+
+			got := newMarkdownConverter(DefaultBaseURL+tutorialPath+name).Convert(content) + "\n"
+
+			golden := strings.TrimSuffix(file, ".html") + ".md"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("markdown mismatch for %s\n--- got ---\n%s\n--- want ---\n%s", file, got, want)
+			}
+		})
+	}
+}
+
+func TestEscapeLineStart(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "# 标题", want: `\# 标题`},
+		{line: "#标签", want: "#标签"},
+		{line: "- 项目", want: `\- 项目`},
+		{line: "-1 点生命", want: "-1 点生命"},
+		{line: "1. 步骤", want: `1\. 步骤`},
+		{line: "2024) 更新", want: `2024\) 更新`},
+		{line: "1.5 倍", want: "1.5 倍"},
+		{line: "> 引用", want: `\> 引用`},
+		{line: "===", want: `\===`},
+		{line: "普通文本", want: "普通文本"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLineStart(tt.line); got != tt.want {
+			t.Errorf("escapeLineStart(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
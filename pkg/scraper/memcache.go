@@ -0,0 +1,143 @@
+package scraper
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// DefaultMemoryCacheSize 内存缓存默认的大小上限（字节）
+const DefaultMemoryCacheSize = 64 << 20
+
+// memoryEntry 内存缓存条目
+type memoryEntry struct {
+	kind     string
+	key      string
+	value    any
+	storedAt time.Time
+	expires  time.Time
+	size     int64 // JSON编码后的字节数，用于估算占用的内存
+}
+
+// memoryCache 按大小限制的LRU内存缓存，超出上限时淘汰最久未使用的条目。
+// 条目以 种类/key 索引，值在缓存中共享，调用方不能修改
+type memoryCache struct {
+	mu        sync.Mutex
+	maxBytes  int64 // 大小上限，<=0 表示不限制
+	bytes     int64
+	entries   map[string]*list.Element
+	order     *list.List // 从最近使用到最久未使用
+	evictions int64
+}
+
+// newMemoryCache 创建内存缓存
+func newMemoryCache(maxBytes int64) *memoryCache {
+	return &memoryCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get 读取条目并标记为最近使用，返回值和过期时间
+func (c *memoryCache) get(kind string, key string) (any, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[kind+"/"+key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	c.order.MoveToFront(elem)
+	entry := elem.Value.(*memoryEntry)
+	return entry.value, entry.expires, true
+}
+
+// set 写入条目，超出大小上限时淘汰最久未使用的条目；单个条目超过上限时不缓存
+func (c *memoryCache) set(kind string, key string, value any, storedAt time.Time, expires time.Time) {
+	var size int64
+	if data, err := json.Marshal(value); err == nil {
+		size = int64(len(data))
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		utils.Debug("Entry exceeds memory cache size, not caching", "kind", kind, "key", key, "bytes", size, "max_bytes", c.maxBytes)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := kind + "/" + key
+	if elem, ok := c.entries[name]; ok {
+		c.removeElement(elem)
+	}
+	entry := &memoryEntry{kind: kind, key: key, value: value, storedAt: storedAt, expires: expires, size: size}
+	c.entries[name] = c.order.PushFront(entry)
+	c.bytes += size
+
+	for c.maxBytes > 0 && c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		evicted := oldest.Value.(*memoryEntry)
+		c.removeElement(oldest)
+		c.evictions++
+		utils.Debug("Evicted memory cache entry", "kind", evicted.kind, "key", evicted.key, "bytes", evicted.size)
+	}
+}
+
+// remove 删除条目，条目存在时返回true
+func (c *memoryCache) remove(kind string, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[kind+"/"+key]
+	if !ok {
+		return false
+	}
+	c.removeElement(elem)
+	return true
+}
+
+// removeElement 从索引和LRU链表中删除条目，调用方需持有锁
+func (c *memoryCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*memoryEntry)
+	delete(c.entries, entry.kind+"/"+entry.key)
+	c.bytes -= entry.size
+}
+
+// list 按最近使用的顺序返回所有条目，不改变使用顺序
+func (c *memoryCache) list() []memoryEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]memoryEntry, 0, c.order.Len())
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, *elem.Value.(*memoryEntry))
+	}
+	return entries
+}
+
+// usage 返回当前占用的字节数和累计淘汰的条目数
+func (c *memoryCache) usage() (int64, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bytes, c.evictions
+}
+
+// memoryCached 读取内存缓存中的条目，过期但仍可使用时在后台刷新，过期太久时视为未命中
+func memoryCached[T any](b *Browser, kind string, key string) (T, bool) {
+	var zero T
+	value, expires, ok := b.memory.get(kind, key)
+	if !ok {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	if !ok || !b.serveCached(kind, key, expires) {
+		return zero, false
+	}
+	b.stats.memoryHits.Add(1)
+	return typed, true
+}
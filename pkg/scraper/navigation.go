@@ -1,147 +1,368 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/go-rod/rod"
+	"github.com/PuerkitoBio/goquery"
 	"genshin-starcraft-mcp/pkg/models"
 	"genshin-starcraft-mcp/pkg/utils"
 )
 
-// GetNavigation 获取导航目录
-func (b *Browser) GetNavigation() ([]models.NavigationItem, error) {
-	utils.Debug("Getting navigation")
+// GetNavigation 获取导航目录，优先使用内存和磁盘缓存，缓存过期但仍可使用时在后台刷新；离线模式下从快照读取，获取失败时使用内嵌数据集
+func (b *Browser) GetNavigation(ctx context.Context) ([]models.NavigationItem, error) {
+	if b.offline != nil {
+		b.recordSnapshotSource(ctx, b.offline)
+		return b.offline.servedNavigation()
+	}
 
-	// 导航到包含导航菜单的页面
-	page, err := b.NewPage("https://act.mihoyo.com/ys/ugc/tutorial/detail/mh29wpicgvh0")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create navigation page: %w", err)
+	if items, ok := memoryCached[[]models.NavigationItem](b, PageKindNavigation, navigationCacheKey); ok {
+		return items, nil
+	}
+
+	// 内存中没有时读取磁盘缓存
+	var items []models.NavigationItem
+	if meta, ok := b.disk.load(PageKindNavigation, navigationCacheKey, &items); ok && b.serveCached(PageKindNavigation, navigationCacheKey, meta.Expires) {
+		b.memory.set(PageKindNavigation, navigationCacheKey, items, meta.StoredAt, meta.Expires)
+		b.stats.diskHits.Add(1)
+		return items, nil
 	}
-	defer page.Close()
+	b.stats.misses.Add(1)
+
+	items, err := b.fetchNavigation(ctx)
+	if err != nil && b.useFallback(ctx, navigationHealthPage, err) {
+		if fallback, fallbackErr := b.fallback.servedNavigation(); fallbackErr == nil {
+			b.recordSnapshotSource(ctx, b.fallback)
+			return fallback, nil
+		}
+	}
+	return items, err
+}
 
-	// 等待页面加载完成
-	page.MustWaitLoad()
+// fetchNavigation 获取并解析导航目录，结果写入内存和磁盘缓存，并发的获取只执行一次
+func (b *Browser) fetchNavigation(ctx context.Context) ([]models.NavigationItem, error) {
+	return b.navigationFlight.do(ctx, navigationCacheKey, func(ctx context.Context) ([]models.NavigationItem, error) {
+		items, err := b.scrapeNavigation(ctx)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		b.memory.set(PageKindNavigation, navigationCacheKey, items, now, now.Add(b.cache.NavigationTTL))
+		b.disk.store(PageKindNavigation, navigationCacheKey, items, now.Add(b.cache.NavigationTTL))
+		return items, nil
+	})
+}
 
-	// 等待导航菜单容器出现，使用rod的内置等待方法
-	scrollbarElement, err := page.Timeout(pageLoadTimeout).Element(".tw-scrollbar")
+// scrapeNavigation 获取并解析导航目录，不写入缓存
+func (b *Browser) scrapeNavigation(ctx context.Context) ([]models.NavigationItem, error) {
+	utils.Debug("Getting navigation")
+
+	// 获取包含导航菜单的页面
+	pageURL := b.TutorialURL(navigationPageID)
+	doc, err := b.fetchDocument(ctx, pageURL, ".tw-scrollbar")
 	if err != nil {
-		return nil, fmt.Errorf("failed to find navigation scrollbar: %w", err)
+		return nil, fmt.Errorf("failed to create navigation page: %w", err)
 	}
 
-	// 确保元素可见并有子元素
-	utils.Debug("Found scrollbar, checking for child elements")
+	// 查找导航菜单容器
+	scrollbarElement := doc.Find(".tw-scrollbar").First()
+	if scrollbarElement.Length() == 0 {
+		return nil, selectorError(ctx, ".tw-scrollbar", pageURL, nil)
+	}
 
 	utils.Debug("Found navigation scrollbar")
 
 	// 在导航容器内查找所有链接，等同于 JavaScript 的 document.querySelector('.tw-scrollbar').querySelectorAll('a')
-	linkElements, err := scrollbarElement.Elements("a")
-	if err != nil {
-		return nil, fmt.Errorf("failed to find any links in navigation: %w", err)
+	linkElements := scrollbarElement.Find("a")
+	if linkElements.Length() == 0 {
+		return nil, selectorError(ctx, ".tw-scrollbar a", pageURL, nil)
 	}
 
-	utils.Debug("Found tutorial link elements", "count", len(linkElements))
+	utils.Debug("Found tutorial link elements", "count", linkElements.Length())
+
+	// 按侧边栏的嵌套结构解析为 分类 → 子分类 → 页面 的树
+	navItems := parseNavigationTree(scrollbarElement, nil)
+
+	b.health.record(PageHealth{
+		Page:       navigationHealthPage,
+		Kind:       PageKindNavigation,
+		URL:        pageURL,
+		CheckedAt:  time.Now(),
+		Violations: checkNavigation(navItems),
+	})
+
+	utils.Debug("Navigation completed", "top_level_items", len(navItems), "pages", len(FlattenNavigation(navItems)))
+	return navItems, nil
+}
 
-	var navItems []models.NavigationItem
+// parseNavigationTree 递归解析侧边栏。包含链接的容器如果在第一个链接之前有文本，
+// 该文本作为分类标题，容器内的链接作为分类的子项；没有标题的容器只是布局包装，其子项并入当前层级
+func parseNavigationTree(container *goquery.Selection, path []string) []models.NavigationItem {
+	var items []models.NavigationItem
 
-	for i, element := range linkElements {
-		// 获取标题
-		title := strings.TrimSpace(element.MustText())
-		if title == "" {
-			utils.Debug("Empty title, skipping", "index", i)
+	for _, child := range container.Contents().EachIter() {
+		if goquery.NodeName(child) == "a" {
+			if item, ok := navigationLink(child); ok {
+				item.Path = path
+				items = append(items, item)
+			}
 			continue
 		}
 
-		// 获取URL
-		href, err := element.Attribute("href")
-		if err != nil || href == nil {
-			utils.Debug("Failed to get href for navigation item", "index", i, "title", title)
+		// 不含链接的节点是分类标题或装饰，由 navigationLabel 处理
+		if child.Find("a").Length() == 0 {
 			continue
 		}
 
-		rawURL := *href
+		label := navigationLabel(child)
+		if label == "" {
+			items = append(items, parseNavigationTree(child, path)...)
+			continue
+		}
 
-		// 验证URL包含预期的路径
-		if !strings.Contains(rawURL, "/ys/ugc/tutorial/detail/") {
-			utils.Debug("URL doesn't contain expected path, skipping", "index", i, "url", rawURL)
+		children := parseNavigationTree(child, append(slices.Clone(path), label))
+		if len(children) == 0 {
 			continue
 		}
 
-		// 提取ID - 从URL中提取最后的部分
-		parts := strings.Split(rawURL, "/")
-		id := ""
-		if len(parts) > 0 {
-			id = parts[len(parts)-1]
+		items = append(items, models.NavigationItem{
+			Title:    label,
+			Path:     path,
+			Children: children,
+		})
+		utils.Debug("Added navigation category", "title", label, "path", path, "children", len(children))
+	}
+
+	return items
+}
+
+// navigationLabel 获取容器在第一个链接之前的文本，作为分类标题
+func navigationLabel(group *goquery.Selection) string {
+	var parts []string
+	for _, child := range group.Contents().EachIter() {
+		if goquery.NodeName(child) == "a" || child.Find("a").Length() > 0 {
+			break
+		}
+		if text := elementText(child); text != "" {
+			parts = append(parts, text)
 		}
+	}
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
 
-		if id == "" {
-			utils.Debug("Failed to extract ID from URL", "index", i, "url", rawURL)
-			continue
+// navigationLink 将侧边栏链接解析为导航项，不是教程页面的链接返回false
+func navigationLink(element *goquery.Selection) (models.NavigationItem, bool) {
+	// 获取标题
+	title := elementText(element)
+	if title == "" {
+		utils.Debug("Empty title, skipping")
+		return models.NavigationItem{}, false
+	}
+
+	// 获取URL
+	rawURL, ok := element.Attr("href")
+	if !ok {
+		utils.Debug("Failed to get href for navigation item", "title", title)
+		return models.NavigationItem{}, false
+	}
+
+	// 验证URL包含预期的路径
+	if !strings.Contains(rawURL, tutorialPath) {
+		utils.Debug("URL doesn't contain expected path, skipping", "url", rawURL)
+		return models.NavigationItem{}, false
+	}
+
+	// 提取ID - 从URL中提取最后的部分
+	parts := strings.Split(rawURL, "/")
+	id := ""
+	if len(parts) > 0 {
+		id = parts[len(parts)-1]
+	}
+
+	if id == "" {
+		utils.Debug("Failed to extract ID from URL", "url", rawURL)
+		return models.NavigationItem{}, false
+	}
+
+	utils.Debug("Added navigation item", "title", title, "id", id)
+	return models.NavigationItem{
+		Title: title,
+		URL:   id, // 只返回ID，内部使用时拼接
+	}, true
+}
+
+// FlattenNavigation 按顺序返回导航树中的所有页面，不包含分类项
+func FlattenNavigation(items []models.NavigationItem) []models.NavigationItem {
+	var pages []models.NavigationItem
+	for _, item := range items {
+		if item.URL != "" {
+			page := item
+			page.Children = nil
+			pages = append(pages, page)
+		}
+		pages = append(pages, FlattenNavigation(item.Children)...)
+	}
+	return pages
+}
+
+// FindNavigationCategory 按标题查找分类或页面，先精确匹配再按包含匹配（忽略大小写）
+func FindNavigationCategory(items []models.NavigationItem, name string) *models.NavigationItem {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	lower := strings.ToLower(name)
+
+	matchers := []func(*models.NavigationItem) bool{
+		func(item *models.NavigationItem) bool { return strings.EqualFold(item.Title, name) },
+		func(item *models.NavigationItem) bool { return strings.Contains(strings.ToLower(item.Title), lower) },
+	}
+	for _, match := range matchers {
+		if found := findNavigationBy(items, match); found != nil {
+			return found
 		}
+	}
+	return nil
+}
 
-		item := models.NavigationItem{
-			Title: title,
-			URL:   id, // 只返回ID，内部使用时拼接
+// findNavigationBy 深度优先查找第一个满足条件的导航项
+func findNavigationBy(items []models.NavigationItem, match func(*models.NavigationItem) bool) *models.NavigationItem {
+	for i := range items {
+		if match(&items[i]) {
+			return &items[i]
 		}
+		if found := findNavigationBy(items[i].Children, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
 
-		navItems = append(navItems, item)
-		utils.Debug("Added navigation item", "index", i, "title", title, "id", id)
+// LimitNavigationDepth 返回只保留前 depth 层的导航树副本，depth<=0 时不限制
+func LimitNavigationDepth(items []models.NavigationItem, depth int) []models.NavigationItem {
+	if depth <= 0 {
+		return items
 	}
 
-	utils.Debug("Navigation completed", "items", len(navItems))
-	return navItems, nil
+	limited := make([]models.NavigationItem, 0, len(items))
+	for _, item := range items {
+		if depth == 1 {
+			item.Children = nil
+		} else {
+			item.Children = LimitNavigationDepth(item.Children, depth-1)
+		}
+		limited = append(limited, item)
+	}
+	return limited
 }
 
-// GetTutorial 获取教程内容，接收ID并内部拼接URL
-func (b *Browser) GetTutorial(id string) (*models.Tutorial, error) {
+// GetTutorial 获取教程内容，优先使用内存和磁盘缓存，缓存过期但仍可使用时在后台刷新；离线模式下从快照读取，获取失败时使用内嵌数据集
+func (b *Browser) GetTutorial(ctx context.Context, id string) (*models.Tutorial, error) {
+	if b.offline != nil {
+		b.recordSnapshotSource(ctx, b.offline)
+		return b.offline.servedTutorial(id)
+	}
+
+	if tutorial, ok := memoryCached[*models.Tutorial](b, PageKindTutorial, id); ok {
+		return tutorial, nil
+	}
+
+	// 内存中没有时读取磁盘缓存
+	var tutorial models.Tutorial
+	if meta, ok := b.disk.load(PageKindTutorial, id, &tutorial); ok && b.serveCached(PageKindTutorial, id, meta.Expires) {
+		b.memory.set(PageKindTutorial, id, &tutorial, meta.StoredAt, meta.Expires)
+		b.stats.diskHits.Add(1)
+		return &tutorial, nil
+	}
+	b.stats.misses.Add(1)
+
+	fetched, err := b.fetchTutorial(ctx, id)
+	if err != nil && b.useFallback(ctx, "tutorial/"+id, err) {
+		if fallback, fallbackErr := b.fallback.servedTutorial(id); fallbackErr == nil {
+			b.recordSnapshotSource(ctx, b.fallback)
+			return fallback, nil
+		}
+	}
+	return fetched, err
+}
+
+// fetchTutorial 获取并解析教程，结果写入内存和磁盘缓存，同一教程ID的并发获取只执行一次
+func (b *Browser) fetchTutorial(ctx context.Context, id string) (*models.Tutorial, error) {
+	return b.tutorialFlight.do(ctx, id, func(ctx context.Context) (*models.Tutorial, error) {
+		tutorial, err := b.scrapeTutorial(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		b.memory.set(PageKindTutorial, id, tutorial, tutorial.LastUpdated, tutorial.CacheExpiry)
+		b.disk.store(PageKindTutorial, id, tutorial, tutorial.CacheExpiry)
+		return tutorial, nil
+	})
+}
+
+// scrapeTutorial 获取并解析教程，接收ID并内部拼接URL，不写入缓存
+func (b *Browser) scrapeTutorial(ctx context.Context, id string) (*models.Tutorial, error) {
 	utils.Debug("Getting tutorial", "id", id)
 
 	// 内部拼接完整URL
-	fullURL := fmt.Sprintf("https://act.mihoyo.com/ys/ugc/tutorial/detail/%s", id)
+	fullURL := b.TutorialURL(id)
 
-	page, err := b.NewPage(fullURL)
+	doc, err := b.fetchDocument(ctx, fullURL, "h1", ".doc-view")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tutorial page: %w", err)
 	}
-	defer page.Close()
-
-	// 等待页面加载完成
-	page.MustWaitLoad()
 
 	// 获取标题
-	titleElement, err := page.Timeout(elementTimeout).Element("h1")
-	if err != nil {
-		return nil, fmt.Errorf("failed to find title: %w", err)
+	titleElement := doc.Find("h1").First()
+	if titleElement.Length() == 0 {
+		return nil, selectorError(ctx, "h1", fullURL, nil)
 	}
-	title := strings.TrimSpace(titleElement.MustText())
+	title := elementText(titleElement)
 
 	// 获取主要内容，尝试多个选择器
-	var contentElement *rod.Element
+	var contentElement *goquery.Selection
 	selectors := []string{".doc-view"}
 
 	for _, selector := range selectors {
-		contentElement, err = page.Timeout(elementTimeout).Element(selector)
-		if err == nil {
+		if found := doc.Find(selector).First(); found.Length() > 0 {
+			contentElement = found
 			utils.Debug("Found content element", "selector", selector)
 			break
 		}
 	}
 
 	if contentElement == nil {
-		return nil, fmt.Errorf("failed to find content with any selector: %w", err)
+		return nil, selectorError(ctx, strings.Join(selectors, ", "), fullURL, nil)
 	}
 
-	content := strings.TrimSpace(contentElement.MustText())
+	// 正文转换为Markdown，保留标题层级、列表、表格和链接，相对链接按页面地址补全
+	conv := newMarkdownConverter(fullURL)
+	content := conv.Convert(contentElement)
+
+	// 按标题层级解析章节树
+	sections := parseSections(contentElement, conv, title)
 
 	tutorial := &models.Tutorial{
 		URL:         id, // 存储ID而不是完整URL
 		Title:       title,
 		Content:     content,
+		Summary:     summarize(contentElement),
+		Outline:     outlineOf(sections),
+		Sections:    sections,
 		LastUpdated: time.Now(),
-		CacheExpiry: time.Now().Add(24 * time.Hour),
+		CacheExpiry: time.Now().Add(b.cache.TutorialTTL),
 	}
 
-	utils.Debug("Tutorial retrieved", "title", title, "content_length", len(content))
+	b.health.record(PageHealth{
+		Page:       "tutorial/" + id,
+		Kind:       PageKindTutorial,
+		URL:        fullURL,
+		CheckedAt:  time.Now(),
+		Violations: checkTutorial(tutorial),
+	})
+
+	utils.Debug("Tutorial retrieved", "title", title, "content_length", len(content), "sections", len(sections))
 	return tutorial, nil
-}
\ No newline at end of file
+}
@@ -1,12 +1,13 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
 	"time"
 
-	"github.com/go-rod/rod"
+	"github.com/PuerkitoBio/goquery"
 	"genshin-starcraft-mcp/pkg/models"
 	"genshin-starcraft-mcp/pkg/utils"
 )
@@ -14,7 +15,7 @@ import (
 // 全局正则表达式，避免重复编译
 var nodeNameRegex = regexp.MustCompile(`^\d+\.\s*`)
 
-// 全局节点类型映射表
+// 内置的节点类型映射表，导航目录无法获取时作为后备
 var nodeTypeMap = map[string]map[string]string{
 	"服务器节点": {
 		"执行节点":       "mhw66orrrfkm",  // 服务器执行节点
@@ -34,11 +35,11 @@ var nodeTypeMap = map[string]map[string]string{
 
 
 // GetNodeGraphs 获取节点图列表
-func (b *Browser) GetNodeGraphs(clientType string, nodeType string) ([]models.NodeGraphItem, error) {
+func (b *Browser) GetNodeGraphs(ctx context.Context, clientType string, nodeType string) ([]models.NodeGraphItem, error) {
 	utils.Debug("Getting node graphs", "client_type", clientType, "node_type", nodeType)
 
 	// 获取完整的页面数据（包含所有节点详情）
-	pageData, err := b.getNodeGraphPageData(clientType, nodeType)
+	pageData, err := b.getNodeGraphPageData(ctx, clientType, nodeType)
 	if err != nil {
 		utils.Error("Failed to get node graph page data", "client_type", clientType, "node_type", nodeType, "error", err)
 		return nil, err
@@ -61,11 +62,11 @@ func (b *Browser) GetNodeGraphs(clientType string, nodeType string) ([]models.No
 }
 
 // GetNodeGraphDetails 获取节点图详细信息
-func (b *Browser) GetNodeGraphDetails(clientType string, nodeType string, nodeName string) (*models.NodeGraphDetails, error) {
+func (b *Browser) GetNodeGraphDetails(ctx context.Context, clientType string, nodeType string, nodeName string) (*models.NodeGraphDetails, error) {
 	utils.Debug("Getting node graph details", "client_type", clientType, "node_type", nodeType, "node_name", nodeName)
 
 	// 获取完整的页面数据
-	pageData, err := b.getNodeGraphPageData(clientType, nodeType)
+	pageData, err := b.getNodeGraphPageData(ctx, clientType, nodeType)
 	if err != nil {
 		utils.Error("Failed to get node graph page data", "client_type", clientType, "node_type", nodeType, "error", err)
 		return nil, err
@@ -89,121 +90,138 @@ func (b *Browser) GetNodeGraphDetails(clientType string, nodeType string, nodeNa
 	}
 
 	utils.Error("Node not found in page data", "client_type", clientType, "node_type", nodeType, "node_name", nodeName, "available_nodes", len(pageData.Nodes))
-	return nil, fmt.Errorf("node not found: %s", nodeName)
+	return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, nodeName)
 }
 
 // getNodeGraphPageData 获取节点图页面数据（共享的解析和缓存逻辑）
-func (b *Browser) getNodeGraphPageData(clientType string, nodeType string) (*models.NodeGraphPage, error) {
+func (b *Browser) getNodeGraphPageData(ctx context.Context, clientType string, nodeType string) (*models.NodeGraphPage, error) {
 	// 生成缓存key
 	cacheKey := fmt.Sprintf("%s_%s", clientType, nodeType)
 	utils.Debug("Getting node graph page data", "cache_key", cacheKey)
 
-	// 检查缓存
-	if cachedPage, exists := b.nodeGraphCache[cacheKey]; exists {
+	if b.offline != nil {
+		b.recordSnapshotSource(ctx, b.offline)
+		return b.offline.servedNodeGraphPage(clientType, nodeType)
+	}
+
+	// 检查缓存，过期但仍可使用时在后台刷新
+	if cachedPage, ok := memoryCached[*models.NodeGraphPage](b, PageKindNodeGraph, cacheKey); ok {
 		utils.Debug("Using cached node graph page", "cache_key", cacheKey, "count", len(cachedPage.Nodes), "last_updated", cachedPage.LastUpdated)
 		return cachedPage, nil
 	}
+
+	// 内存中没有时读取磁盘缓存
+	var diskPage models.NodeGraphPage
+	if meta, ok := b.disk.load(PageKindNodeGraph, cacheKey, &diskPage); ok && b.serveCached(PageKindNodeGraph, cacheKey, meta.Expires) {
+		b.memory.set(PageKindNodeGraph, cacheKey, &diskPage, meta.StoredAt, meta.Expires)
+		b.stats.diskHits.Add(1)
+		return &diskPage, nil
+	}
 	utils.Debug("Cache miss, fetching fresh data", "cache_key", cacheKey)
+	b.stats.misses.Add(1)
+
+	pageData, err := b.fetchNodeGraphPage(ctx, clientType, nodeType)
+	if err != nil && b.useFallback(ctx, nodeGraphPageName(clientType, nodeType), err) {
+		if fallback, fallbackErr := b.fallback.servedNodeGraphPage(clientType, nodeType); fallbackErr == nil {
+			b.recordSnapshotSource(ctx, b.fallback)
+			return fallback, nil
+		}
+	}
+	return pageData, err
+}
 
+// fetchNodeGraphPage 查找节点类型对应的页面ID，获取并解析页面
+func (b *Browser) fetchNodeGraphPage(ctx context.Context, clientType string, nodeType string) (*models.NodeGraphPage, error) {
 	// 根据客户端类型和节点类型获取对应的ID
-	graphID := b.getNodeGraphID(clientType, nodeType)
+	graphID := b.getNodeGraphID(ctx, clientType, nodeType)
 	if graphID == "" {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
 		// 提供更友好的错误信息，包含支持的node_type列表
-		supportedTypes := b.getSupportedNodeTypes(clientType)
+		supportedTypes := b.KnownNodeTypes().Types(clientType)
 
-		errMsg := fmt.Sprintf("invalid node type '%s' for client_type '%s'. Supported node types: %v", nodeType, clientType, supportedTypes)
 		utils.Error("Failed to get node graph ID", "client_type", clientType, "node_type", nodeType, "supported_types", supportedTypes)
-		return nil, fmt.Errorf(errMsg)
+		return nil, fmt.Errorf("%w '%s' for client_type '%s'. Supported node types: %v", ErrUnknownNodeType, nodeType, clientType, supportedTypes)
 	}
 
-	utils.Debug("Creating page for node graph", "graph_id", graphID)
-
-	// 获取页面内容
-	pageURL := fmt.Sprintf("https://act.mihoyo.com/ys/ugc/tutorial/detail/%s", graphID)
-	utils.Debug("Creating page with URL", "url", pageURL, "graph_id", graphID)
-	page, err := b.NewPage(pageURL)
-	if err != nil {
-		utils.Error("Failed to create page", "graph_id", graphID, "url", pageURL, "error", err)
-		return nil, fmt.Errorf("failed to create page: %w", err)
-	}
-	defer page.Close()
+	return b.loadNodeGraphPage(ctx, clientType, nodeType, graphID)
+}
 
-	utils.Debug("Page created, waiting for load...", "graph_id", graphID)
+// loadNodeGraphPage 获取并解析节点类型页面，结果写入内存和磁盘缓存，同一节点类型的并发获取只执行一次
+func (b *Browser) loadNodeGraphPage(ctx context.Context, clientType string, nodeType string, graphID string) (*models.NodeGraphPage, error) {
+	cacheKey := fmt.Sprintf("%s_%s", clientType, nodeType)
+	return b.nodeGraphFlight.do(ctx, cacheKey, func(ctx context.Context) (*models.NodeGraphPage, error) {
+		pageData, err := b.scrapeNodeGraphPage(ctx, clientType, nodeType, graphID)
+		if err != nil {
+			return nil, err
+		}
 
-	// 等待页面加载完成
-	page.MustWaitLoad()
-	utils.Debug("Page loaded, checking content...", "graph_id", graphID)
+		// 缓存完整的页面数据
+		if len(pageData.Nodes) > 0 {
+			expires := pageData.LastUpdated.Add(b.cache.NodeGraphTTL)
+			b.memory.set(PageKindNodeGraph, cacheKey, pageData, pageData.LastUpdated, expires)
+			b.disk.store(PageKindNodeGraph, cacheKey, pageData, expires)
+			utils.Debug("Cached complete node graph page", "cache_key", cacheKey, "count", len(pageData.Nodes), "last_updated", pageData.LastUpdated)
+			utils.Info("Successfully cached node graph page", "cache_key", cacheKey, "nodes_count", len(pageData.Nodes))
+		} else {
+			utils.Debug("No nodes found in page, skipping cache", "cache_key", cacheKey, "graph_id", graphID)
+		}
+		return pageData, nil
+	})
+}
 
-	// 检查页面是否成功加载
-	pageInfo, err := page.Info()
-	if err != nil {
-		utils.Error("Failed to get page info after load", "graph_id", graphID, "error", err)
-		return nil, fmt.Errorf("failed to get page info after load: %w", err)
-	}
-	utils.Debug("Page loaded successfully", "graph_id", graphID, "url", pageInfo.URL, "title", pageInfo.Title)
+// scrapeNodeGraphPage 获取并解析节点类型页面，检查解析结果，不写入缓存
+func (b *Browser) scrapeNodeGraphPage(ctx context.Context, clientType string, nodeType string, graphID string) (*models.NodeGraphPage, error) {
+	utils.Debug("Creating page for node graph", "graph_id", graphID)
 
-	// 等待主要内容区域加载
-	utils.Debug("Waiting for main content...", "graph_id", graphID)
-	_, err = page.Timeout(pageLoadTimeout).Element("div.doc-view")
+	// 获取页面内容
+	pageURL := b.TutorialURL(graphID)
+	utils.Debug("Fetching document with URL", "url", pageURL, "graph_id", graphID)
+	doc, err := b.fetchDocument(ctx, pageURL, "div.doc-view")
 	if err != nil {
-		utils.Debug("Main content not found", "graph_id", graphID, "error", err)
-		// 继续尝试解析，可能页面结构不同
-	} else {
-		utils.Debug("Main content found", "graph_id", graphID)
+		utils.Error("Failed to fetch page", "graph_id", graphID, "url", pageURL, "error", err)
+		return nil, fmt.Errorf("failed to create page: %w", err)
 	}
 
-	// 再次检查页面内容状态
-	utils.Debug("Checking page content status...", "graph_id", graphID)
-
-	utils.Debug("Starting page parsing...", "graph_id", graphID)
+	utils.Debug("Page loaded successfully", "graph_id", graphID, "title", strings.TrimSpace(doc.Find("title").Text()))
 
 	// 解析完整的页面结构，包括所有节点的详细信息
 	utils.Debug("Starting complete page parsing...", "graph_id", graphID)
-	pageData, err := b.parseCompleteNodeGraphPage(page, clientType, nodeType)
+	pageData, err := b.parseCompleteNodeGraphPage(ctx, doc, clientType, nodeType)
 	if err != nil {
 		utils.Error("Failed to parse page", "graph_id", graphID, "error", err)
 		return nil, fmt.Errorf("failed to parse page: %w", err)
 	}
 	utils.Debug("Page parsing completed successfully", "graph_id", graphID, "nodes_count", len(pageData.Nodes))
 
-	// 缓存完整的页面数据
-	if len(pageData.Nodes) > 0 {
-		b.nodeGraphCache[cacheKey] = pageData
-		utils.Debug("Cached complete node graph page", "cache_key", cacheKey, "count", len(pageData.Nodes), "last_updated", pageData.LastUpdated)
-		utils.Info("Successfully cached node graph page", "cache_key", cacheKey, "nodes_count", len(pageData.Nodes))
-	} else {
-		utils.Debug("No nodes found in page, skipping cache", "cache_key", cacheKey, "graph_id", graphID)
-	}
+	// 检查解析结果是否符合预期的页面结构
+	b.health.record(PageHealth{
+		Page:       nodeGraphPageName(clientType, nodeType),
+		Kind:       PageKindNodeGraph,
+		URL:        pageURL,
+		CheckedAt:  time.Now(),
+		Violations: checkNodeGraphPage(doc, pageData),
+	})
 
 	return pageData, nil
 }
 
 
-// getSupportedNodeTypes 获取支持的节点类型列表
-func (b *Browser) getSupportedNodeTypes(clientType string) []string {
-	var supportedTypes []string
-	if clientTypes, ok := nodeTypeMap[clientType]; ok {
-		for nodeType := range clientTypes {
-			supportedTypes = append(supportedTypes, nodeType)
-		}
+// getNodeGraphID 根据客户端类型和节点类型获取对应的页面ID，未知的节点类型会触发一次节点类型的重新发现
+func (b *Browser) getNodeGraphID(ctx context.Context, clientType string, nodeType string) string {
+	if graphID := b.NodeTypes(ctx)[clientType][nodeType]; graphID != "" {
+		return graphID
 	}
-	return supportedTypes
-}
 
-// getNodeGraphID 根据客户端类型和节点类型获取对应的页面ID
-func (b *Browser) getNodeGraphID(clientType string, nodeType string) string {
-	if clientTypes, ok := nodeTypeMap[clientType]; ok {
-		if graphID, ok := clientTypes[nodeType]; ok {
-			return graphID
-		}
-	}
-	return ""
+	// 站点可能新增了节点类型
+	b.refreshNodeTypes(ctx, true)
+	return b.KnownNodeTypes()[clientType][nodeType]
 }
 
-
-
 // parseCompleteNodeGraphPage 解析完整的节点图页面，包括所有节点的详细信息
-func (b *Browser) parseCompleteNodeGraphPage(page *rod.Page, clientType string, nodeType string) (*models.NodeGraphPage, error) {
+func (b *Browser) parseCompleteNodeGraphPage(ctx context.Context, doc *goquery.Document, clientType string, nodeType string) (*models.NodeGraphPage, error) {
 	utils.Debug("Starting optimized node graph page parsing", "client_type", clientType, "node_type", nodeType)
 
 	pageData := &models.NodeGraphPage{
@@ -214,7 +232,11 @@ func (b *Browser) parseCompleteNodeGraphPage(page *rod.Page, clientType string,
 	}
 
 	// 使用优化的一次性解析所有节点的详细信息
-	pageData.Nodes = b.parseAllNodeDetails(page, clientType, nodeType)
+	nodes, err := b.parseAllNodeDetails(ctx, doc, clientType, nodeType)
+	if err != nil {
+		return nil, err
+	}
+	pageData.Nodes = nodes
 
 	utils.Debug("Parsed complete node graph page", "total_nodes", len(pageData.Nodes), "client_type", clientType, "node_type", nodeType)
 
@@ -226,24 +248,12 @@ func (b *Browser) parseCompleteNodeGraphPage(page *rod.Page, clientType string,
 }
 
 // getSiblingsUntilNextH2 获取从指定元素开始的所有兄弟元素，直到遇到下一个h2
-func (b *Browser) getSiblingsUntilNextH2(startElement *rod.Element) []*rod.Element {
-	var siblings []*rod.Element
-	current := startElement
-
-	for {
-		next, err := current.Next()
-		if err != nil || next == nil {
-			break
-		}
-
-		// 如果遇到下一个h2，停止
-		if next.MustMatches("h2") {
-			break
-		}
+func (b *Browser) getSiblingsUntilNextH2(startElement *goquery.Selection) []*goquery.Selection {
+	var siblings []*goquery.Selection
 
+	startElement.NextUntil("h2").Each(func(_ int, next *goquery.Selection) {
 		siblings = append(siblings, next)
-		current = next
-	}
+	})
 
 	return siblings
 }
@@ -266,8 +276,8 @@ type NodeBuilder struct {
 }
 
 // NewNodeBuilder 创建新的节点构建器
-func (b *Browser) NewNodeBuilder(h2Element *rod.Element) *NodeBuilder {
-	rawName := strings.TrimSpace(h2Element.MustText())
+func (b *Browser) NewNodeBuilder(h2Element *goquery.Selection) *NodeBuilder {
+	rawName := elementText(h2Element)
 	nodeName := b.cleanNodeName(rawName)
 
 	utils.Debug("Starting new node", "original_name", rawName, "cleaned_name", nodeName)
@@ -279,22 +289,22 @@ func (b *Browser) NewNodeBuilder(h2Element *rod.Element) *NodeBuilder {
 }
 
 // AddContent 添加内容到当前节点
-func (nb *NodeBuilder) AddContent(element *rod.Element) {
+func (nb *NodeBuilder) AddContent(element *goquery.Selection) {
 	if nb == nil {
 		return
 	}
 
 	// 简化元素识别
 	elementTag := "unknown"
-	if element.MustMatches("p") {
+	if element.Is("p") {
 		elementTag = "p"
-	} else if element.MustMatches("div.table-wrapper") {
+	} else if element.Is("div.table-wrapper") {
 		elementTag = "table-wrapper"
 	} else {
 		elementTag = "other"
 	}
 
-	text := strings.TrimSpace(element.MustText())
+	text := elementText(element)
 	if len(text) > 50 {
 		text = text[:50] + "..."
 	}
@@ -307,7 +317,7 @@ func (nb *NodeBuilder) AddContent(element *rod.Element) {
 
 	switch elementTag {
 	case "p":
-		text := strings.TrimSpace(element.MustText())
+		text := elementText(element)
 		if text != "" {
 			// 检查是否是示例内容
 			if nb.hasTable && (strings.Contains(text, "示例") || strings.Contains(text, "用法")) {
@@ -332,48 +342,44 @@ func (nb *NodeBuilder) AddContent(element *rod.Element) {
 }
 
 // parseTable 解析表格内容
-func (nb *NodeBuilder) parseTable(divWrapperElement *rod.Element) {
+func (nb *NodeBuilder) parseTable(divWrapperElement *goquery.Selection) {
 	// 在div.table-wrapper内部查找table元素
-	tableElement, err := divWrapperElement.Element("table")
-	if err != nil {
-		utils.Debug("Failed to find table element in div wrapper", "error", err)
+	tableElement := divWrapperElement.Find("table").First()
+	if tableElement.Length() == 0 {
+		utils.Debug("Failed to find table element in div wrapper")
 		return
 	}
 
 	// 使用CSS选择器获取表格行
-	tableRows, err := tableElement.Elements("tbody tr")
-	if err != nil {
-		utils.Debug("Failed to parse table with tbody tr", "error", err)
+	tableRows := tableElement.Find("tbody tr")
+	if tableRows.Length() == 0 {
+		utils.Debug("Failed to parse table with tbody tr")
 		// 如果没有tbody，尝试直接获取tr
-		tableRows, err = tableElement.Elements("tr")
-		if err != nil {
-			utils.Debug("Failed to parse table with tr", "error", err)
+		tableRows = tableElement.Find("tr")
+		if tableRows.Length() == 0 {
+			utils.Debug("Failed to parse table with tr")
 			return
 		}
 	}
 
-	utils.Debug("Parsing table with CSS selectors", "node_name", nb.name, "total_rows", len(tableRows))
+	utils.Debug("Parsing table with CSS selectors", "node_name", nb.name, "total_rows", tableRows.Length())
 
 	// 遍历所有行
-	for i, row := range tableRows {
+	for i, row := range tableRows.EachIter() {
 		// 使用CSS选择器获取单元格
-		cells, err := row.Elements("td")
-		if err != nil {
-			utils.Debug("Failed to get cells for row", "node_name", nb.name, "row", i, "error", err)
-			continue
-		}
+		cells := row.Find("td")
 
 		// 确保有足够的列（参数类型、参数名、类型、说明）
-		if len(cells) < 4 {
-			utils.Debug("Skipping row with insufficient columns", "node_name", nb.name, "row", i, "columns", len(cells))
+		if cells.Length() < 4 {
+			utils.Debug("Skipping row with insufficient columns", "node_name", nb.name, "row", i, "columns", cells.Length())
 			continue
 		}
 
 		// 直接获取单元格内容
-		paramType := strings.TrimSpace(cells[0].MustText())   // 参数类型（入参/出参）
-		paramName := strings.TrimSpace(cells[1].MustText())   // 参数名
-		dataType := strings.TrimSpace(cells[2].MustText())   // 类型
-		description := strings.TrimSpace(cells[3].MustText()) // 说明
+		paramType := elementText(cells.Eq(0))   // 参数类型（入参/出参）
+		paramName := elementText(cells.Eq(1))   // 参数名
+		dataType := elementText(cells.Eq(2))    // 类型
+		description := elementText(cells.Eq(3)) // 说明
 
 		utils.Debug("Parsing table row with CSS", "node_name", nb.name, "row", i, "param_type", paramType, "param_name", paramName)
 
@@ -436,43 +442,28 @@ func (nb *NodeBuilder) Build() *models.NodeGraphDetails {
 }
 
 // parseAllNodeDetails 一次性解析所有节点的详细信息，使用流式算法避免O(n²)复杂度
-func (b *Browser) parseAllNodeDetails(page *rod.Page, clientType string, nodeType string) []*models.NodeGraphDetails {
+func (b *Browser) parseAllNodeDetails(ctx context.Context, doc *goquery.Document, clientType string, nodeType string) ([]*models.NodeGraphDetails, error) {
 	utils.Debug("Starting optimized node graph page parsing", "client_type", clientType, "node_type", nodeType)
 
 	// 获取所有h1和h2元素
-	h1Elements, err := page.Elements("h1")
-	if err != nil {
-		utils.Error("Failed to get h1 elements", "error", err)
-		return nil
-	}
-
-	h2Elements, err := page.Elements("h2")
-	if err != nil {
-		utils.Error("Failed to get h2 elements", "error", err)
-		return nil
-	}
+	h1Elements := doc.Find("h1")
+	h2Elements := doc.Find("h2")
 
-	utils.Debug("Found elements for streaming parsing", "h1_count", len(h1Elements), "h2_count", len(h2Elements))
+	utils.Debug("Found elements for streaming parsing", "h1_count", h1Elements.Length(), "h2_count", h2Elements.Length())
 
 	// 构建h1分类映射：h2的文本对应的h1分类
 	h2ToH1Category := make(map[string]string)
 	currentH1Category := "未分类"
 
 	// 遍历所有元素来建立分类映射
-	allElements, err := page.Elements("h1, h2")
-	if err != nil {
-		utils.Error("Failed to get all elements", "error", err)
-		return nil
-	}
-
-	for _, element := range allElements {
-		if element.MustMatches("h1") {
+	for _, element := range doc.Find("h1, h2").EachIter() {
+		if element.Is("h1") {
 			// 更新当前h1分类
-			currentH1Category = strings.TrimSpace(element.MustText())
+			currentH1Category = elementText(element)
 			utils.Debug("Found h1 category", "category", currentH1Category)
-		} else if element.MustMatches("h2") {
+		} else if element.Is("h2") {
 			// 为h2元素分配当前的h1分类
-			h2Text := strings.TrimSpace(element.MustText())
+			h2Text := elementText(element)
 			h2ToH1Category[h2Text] = currentH1Category
 			utils.Debug("Mapped h2 to h1 category", "h2", h2Text, "h1_category", currentH1Category)
 		}
@@ -490,9 +481,15 @@ func (b *Browser) parseAllNodeDetails(page *rod.Page, clientType string, nodeTyp
 	var nodes []*models.NodeGraphDetails
 
 	// 流式处理：对每个h2元素，直接处理其后续兄弟元素
-	for i, h2Element := range h2Elements {
+	for i, h2Element := range h2Elements.EachIter() {
+		// 请求取消时中止解析
+		if err := ctx.Err(); err != nil {
+			utils.Debug("Parsing canceled", "parsed_nodes", len(nodes), "client_type", clientType, "node_type", nodeType)
+			return nil, err
+		}
+
 		// 获取节点名称
-		rawName := strings.TrimSpace(h2Element.MustText())
+		rawName := elementText(h2Element)
 		nodeName := b.cleanNodeName(rawName)
 		h1Category := h2ToH1Category[rawName] // 获取对应的h1分类
 
@@ -534,7 +531,7 @@ func (b *Browser) parseAllNodeDetails(page *rod.Page, clientType string, nodeTyp
 	}
 
 	utils.Debug("Completed streaming parsing", "total_nodes", len(nodes), "client_type", clientType, "node_type", nodeType)
-	return nodes
+	return nodes, nil
 }
 
 // cleanNodeName 清理节点名称，去掉开头的数字序号和特殊字符
@@ -0,0 +1,188 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/models"
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// 节点类型发现相关常量
+const (
+	// 从导航目录发现的节点类型的有效期，过期后下次查询时重新发现
+	nodeTypesRefreshInterval = 24 * time.Hour
+
+	// 发现失败后的重试间隔，查询未知节点类型时最多按此间隔强制重新发现
+	nodeTypesRetryInterval = 5 * time.Minute
+)
+
+// 标题形如 服务器执行节点、客户端查询节点 的节点类型页面
+var nodeTypeTitleRegex = regexp.MustCompile(`^(服务器|客户端)(\S+节点)$`)
+
+// NodeTypes 节点类型映射：客户端类型 → 节点类型 → 页面ID
+type NodeTypes map[string]map[string]string
+
+// clone 深拷贝映射
+func (t NodeTypes) clone() NodeTypes {
+	cloned := make(NodeTypes, len(t))
+	for clientType, types := range t {
+		cloned[clientType] = maps.Clone(types)
+	}
+	return cloned
+}
+
+// merge 将 other 中的映射合并进来，同名节点类型以 other 为准
+func (t NodeTypes) merge(other NodeTypes) {
+	for clientType, types := range other {
+		if t[clientType] == nil {
+			t[clientType] = make(map[string]string, len(types))
+		}
+		maps.Copy(t[clientType], types)
+	}
+}
+
+// ClientTypes 返回排序后的客户端类型列表
+func (t NodeTypes) ClientTypes() []string {
+	return slices.Sorted(maps.Keys(t))
+}
+
+// Types 返回客户端类型下排序后的节点类型列表
+func (t NodeTypes) Types(clientType string) []string {
+	return slices.Sorted(maps.Keys(t[clientType]))
+}
+
+// Equal 判断两个映射是否完全相同
+func (t NodeTypes) Equal(other NodeTypes) bool {
+	return maps.EqualFunc(t, other, func(a, b map[string]string) bool {
+		return maps.Equal(a, b)
+	})
+}
+
+// KnownNodeTypes 返回当前已知的节点类型映射，不触发导航目录的获取。
+// 优先级：配置覆盖 > 从导航目录发现的 > 内置映射
+func (b *Browser) KnownNodeTypes() NodeTypes {
+	b.nodeTypesMu.RLock()
+	defer b.nodeTypesMu.RUnlock()
+
+	types := NodeTypes(nodeTypeMap).clone()
+	types.merge(b.discoveredNodeTypes)
+	types.merge(b.nodeTypeOverrides)
+	return types
+}
+
+// NodeTypes 返回节点类型映射，发现结果过期时先从导航目录重新发现，失败时使用已知的映射
+func (b *Browser) NodeTypes(ctx context.Context) NodeTypes {
+	b.refreshNodeTypes(ctx, false)
+	return b.KnownNodeTypes()
+}
+
+// refreshNodeTypes 从导航目录重新发现节点类型。force 为false时只在结果过期后发现，
+// 为true时（查询了未知节点类型）只要距上次发现超过重试间隔就重新发现
+func (b *Browser) refreshNodeTypes(ctx context.Context, force bool) {
+	b.nodeTypesMu.RLock()
+	checkedAt, nextCheck := b.nodeTypesCheckedAt, b.nodeTypesNextCheck
+	b.nodeTypesMu.RUnlock()
+
+	now := time.Now()
+	if force && now.Sub(checkedAt) < nodeTypesRetryInterval {
+		return
+	}
+	if !force && now.Before(nextCheck) {
+		return
+	}
+
+	utils.Debug("Discovering node types from navigation", "force", force)
+
+	// 查询了未知节点类型时不使用导航目录的缓存
+	getNavigation := b.GetNavigation
+	if force {
+		getNavigation = b.fetchNavigation
+	}
+	items, err := getNavigation(ctx)
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if err != nil {
+		b.nodeTypesMu.Lock()
+		defer b.nodeTypesMu.Unlock()
+
+		b.nodeTypesCheckedAt = time.Now()
+		b.nodeTypesNextCheck = b.nodeTypesCheckedAt.Add(nodeTypesRetryInterval)
+		utils.Error("Failed to discover node types, using known mapping", "error", err)
+		return
+	}
+
+	b.updateNodeTypes(items)
+}
+
+// updateNodeTypes 用已获取的导航目录更新发现的节点类型
+func (b *Browser) updateNodeTypes(items []models.NavigationItem) {
+	discovered := nodeTypesFromNavigation(items)
+
+	b.nodeTypesMu.Lock()
+	defer b.nodeTypesMu.Unlock()
+
+	b.nodeTypesCheckedAt = time.Now()
+	b.nodeTypesNextCheck = b.nodeTypesCheckedAt.Add(nodeTypesRefreshInterval)
+	b.discoveredNodeTypes = discovered
+
+	for _, clientType := range discovered.ClientTypes() {
+		for _, nodeType := range discovered.Types(clientType) {
+			id := discovered[clientType][nodeType]
+			if builtin, ok := nodeTypeMap[clientType][nodeType]; !ok {
+				utils.Info("Discovered new node type", "client_type", clientType, "node_type", nodeType, "id", id)
+			} else if builtin != id {
+				utils.Info("Node type page moved", "client_type", clientType, "node_type", nodeType, "old_id", builtin, "new_id", id)
+			}
+		}
+	}
+	utils.Debug("Node types discovered", "client_types", len(discovered))
+}
+
+// nodeTypesFromNavigation 从导航树中识别节点类型页面：标题形如 服务器执行节点 的页面，
+// 或位于标题包含 服务器/客户端 的分类下、标题以 节点 结尾的页面
+func nodeTypesFromNavigation(items []models.NavigationItem) NodeTypes {
+	types := NodeTypes{}
+	add := func(clientType string, nodeType string, id string) {
+		if types[clientType] == nil {
+			types[clientType] = map[string]string{}
+		}
+		if _, exists := types[clientType][nodeType]; !exists {
+			types[clientType][nodeType] = id
+		}
+	}
+
+	for _, page := range FlattenNavigation(items) {
+		title := strings.Join(strings.Fields(page.Title), "")
+
+		if match := nodeTypeTitleRegex.FindStringSubmatch(title); match != nil {
+			add(match[1]+"节点", match[2], page.URL)
+			continue
+		}
+
+		if !strings.HasSuffix(title, "节点") || strings.HasPrefix(title, "服务器") || strings.HasPrefix(title, "客户端") {
+			continue
+		}
+
+		// 从最近的分类向上查找客户端类型
+		for i := len(page.Path) - 1; i >= 0; i-- {
+			if strings.Contains(page.Path[i], "服务器") {
+				add("服务器节点", title, page.URL)
+				break
+			}
+			if strings.Contains(page.Path[i], "客户端") {
+				add("客户端节点", title, page.URL)
+				break
+			}
+		}
+	}
+
+	return types
+}
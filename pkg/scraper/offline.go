@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+
+	"genshin-starcraft-mcp/pkg/models"
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// offlineFetcher 离线模式的页面获取器。内容都从快照读取，不访问网络也不启动Chromium，
+// 快照中没有的内容需要获取页面时返回 ErrNotInSnapshot
+type offlineFetcher struct {
+	snapshot *Snapshot
+}
+
+// newOfflineFetcher 打开离线模式使用的快照，name 为空时使用 root 下最新的完整快照
+func newOfflineFetcher(root string, name string) (*offlineFetcher, error) {
+	snapshot, err := ResolveSnapshot(root, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open offline snapshot: %w", err)
+	}
+	if failed := snapshot.Manifest.Failed(); failed > 0 || !snapshot.Manifest.Complete {
+		utils.Info("Offline snapshot is incomplete", "version", snapshot.Manifest.Version, "failed", failed)
+	}
+	return &offlineFetcher{snapshot: snapshot}, nil
+}
+
+// Fetch 离线模式下不获取页面
+func (f *offlineFetcher) Fetch(ctx context.Context, url string) (string, error) {
+	return "", fmt.Errorf("%w: offline mode cannot fetch %s", ErrNotInSnapshot, url)
+}
+
+// Close 离线模式没有需要释放的资源
+func (f *offlineFetcher) Close() error {
+	return nil
+}
+
+// offlineSnapshotOf 返回离线模式使用的快照，非离线模式返回nil
+func offlineSnapshotOf(fetcher Fetcher) *Snapshot {
+	if f, ok := fetcher.(*offlineFetcher); ok {
+		return f.snapshot
+	}
+	return nil
+}
+
+// OfflineSnapshot 返回离线模式使用的快照清单，非离线模式返回nil
+func (b *Browser) OfflineSnapshot() *SnapshotManifest {
+	if b.offline == nil {
+		return nil
+	}
+	return b.offline.Manifest
+}
+
+// servedEntry 查找快照中成功获取的页面，没有时返回 ErrNotInSnapshot
+func (s *Snapshot) servedEntry(path string, what string) error {
+	if entry, ok := s.Entry(path); !ok || entry.Error != "" {
+		return fmt.Errorf("%w: %s in snapshot %s", ErrNotInSnapshot, what, s.Manifest.Version)
+	}
+	return nil
+}
+
+// servedNavigation 读取快照中的导航目录
+func (s *Snapshot) servedNavigation() ([]models.NavigationItem, error) {
+	if err := s.servedEntry(snapshotNavigationFile, "navigation"); err != nil {
+		return nil, err
+	}
+	return s.Navigation()
+}
+
+// servedTutorial 读取快照中的教程
+func (s *Snapshot) servedTutorial(id string) (*models.Tutorial, error) {
+	if err := s.servedEntry(snapshotGuidePath(id), "guide "+id); err != nil {
+		return nil, err
+	}
+	return s.Tutorial(id)
+}
+
+// servedNodeGraphPage 读取快照中的节点类型页面，按快照清单中记录的客户端类型和节点类型查找
+func (s *Snapshot) servedNodeGraphPage(clientType string, nodeType string) (*models.NodeGraphPage, error) {
+	var supportedTypes []string
+	for _, entry := range s.Manifest.Entries {
+		if entry.Kind != PageKindNodeGraph || entry.ClientType != clientType || entry.Error != "" {
+			continue
+		}
+		if entry.NodeType == nodeType {
+			return s.NodeGraphPage(entry.ID)
+		}
+		supportedTypes = append(supportedTypes, entry.NodeType)
+	}
+
+	return nil, fmt.Errorf("%w '%s' for client_type '%s' in snapshot %s. Supported node types: %v", ErrUnknownNodeType, nodeType, clientType, s.Manifest.Version, supportedTypes)
+}
+
+// fallbackSnapshot 实时获取失败时使用的内嵌数据集，录制、回放和离线模式下不使用
+func (opts Options) fallbackSnapshot() *Snapshot {
+	if opts.RecordDir != "" || opts.ReplayDir != "" || opts.Offline {
+		return nil
+	}
+	return EmbeddedSnapshot()
+}
+
+// useFallback 判断获取失败后是否改用内嵌数据集，请求已取消时不使用
+func (b *Browser) useFallback(ctx context.Context, page string, err error) bool {
+	if b.fallback == nil || ctx.Err() != nil {
+		return false
+	}
+	utils.Error("Live fetch failed, trying embedded dataset", "page", page, "version", b.fallback.Manifest.Version, "error", err)
+	return true
+}
@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// RetryPolicy 重试策略：指数退避加随机抖动，并限制单次操作的总耗时
+type RetryPolicy struct {
+	MaxAttempts    int           // 最大尝试次数（含首次），<=1 表示不重试
+	InitialBackoff time.Duration // 首次重试前的等待时间
+	MaxBackoff     time.Duration // 单次等待时间上限
+	Multiplier     float64       // 每次重试等待时间的倍数
+	Jitter         float64       // 随机抖动比例，0.2 表示在 ±20% 范围内浮动
+	Budget         time.Duration // 单次操作（含所有重试和等待）的总时间预算，0 表示不限制
+}
+
+// DefaultRetryPolicy 返回默认重试策略
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		Budget:         90 * time.Second,
+	}
+}
+
+// Do 执行操作，遇到可重试的错误时按策略退避后重试，返回最后一次的错误
+func (p RetryPolicy) Do(ctx context.Context, op string, fn func() error) error {
+	start := time.Now()
+	backoff := p.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			if attempt > 1 {
+				utils.Info("Operation succeeded after retry", "op", op, "attempts", attempt, "elapsed", time.Since(start))
+			}
+			return nil
+		}
+
+		if attempt >= p.MaxAttempts || !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := p.jittered(backoff)
+		if p.Budget > 0 && time.Since(start)+wait > p.Budget {
+			utils.Info("Retry budget exhausted", "op", op, "attempts", attempt, "elapsed", time.Since(start), "error", err)
+			return err
+		}
+
+		utils.Info("Retrying after transient failure", "op", op, "attempt", attempt, "wait", wait, "error", err)
+		if sleepErr := sleepContext(ctx, wait); sleepErr != nil {
+			return sleepErr
+		}
+
+		backoff = p.next(backoff)
+	}
+}
+
+// jittered 为等待时间加上随机抖动
+func (p RetryPolicy) jittered(d time.Duration) time.Duration {
+	if p.Jitter <= 0 || d <= 0 {
+		return d
+	}
+	factor := 1 + p.Jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(d) * factor)
+}
+
+// next 计算下一次的退避时间
+func (p RetryPolicy) next(d time.Duration) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d = time.Duration(float64(d) * multiplier)
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// IsRetryable 判断错误是否为可重试的临时故障：页面导航失败或超时、等待的元素未出现、
+// 临时性的HTTP状态码和浏览器不可用。其它错误（如解析失败、快照或fixture中没有页面）重试也不会成功
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, ErrNavigationTimeout), errors.Is(err, ErrNavigationFailed), errors.Is(err, ErrSelectorMissing):
+		return true
+	case errors.Is(err, ErrBrowserUnavailable):
+		// 浏览器退出或连接断开后会自动重启，重试时使用新的实例
+		return true
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		code := statusErr.StatusCode
+		return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+	}
+
+	return false
+}
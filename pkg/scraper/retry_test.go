@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "canceled", err: fmt.Errorf("fetch: %w", context.Canceled), want: false},
+		{name: "navigation timeout", err: navigationError(context.Background(), "u", context.DeadlineExceeded), want: true},
+		{name: "navigation failed", err: navigationError(context.Background(), "u", errors.New("connection reset by peer")), want: true},
+		{name: "selector missing", err: selectorError(context.Background(), ".doc-view", "u", nil), want: true},
+		{name: "browser unavailable", err: fmt.Errorf("%w: failed to open page", ErrBrowserUnavailable), want: true},
+		{name: "service unavailable", err: &HTTPStatusError{URL: "u", StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "too many requests", err: &HTTPStatusError{URL: "u", StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "not found", err: &HTTPStatusError{URL: "u", StatusCode: http.StatusNotFound}, want: false},
+		{name: "not in snapshot", err: fmt.Errorf("%w: guide x", ErrNotInSnapshot), want: false},
+		{name: "unknown node type", err: fmt.Errorf("%w 'x'", ErrUnknownNodeType), want: false},
+		{name: "missing fixture", err: fmt.Errorf("no recorded fixture: %w", fs.ErrNotExist), want: false},
+		{name: "parse error", err: errors.New("failed to parse html"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := &HTTPStatusError{URL: "u", StatusCode: http.StatusBadGateway}
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		failures     int   // 前几次调用返回的错误次数
+		err          error // 失败时返回的错误
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "succeeds after transient failures", policy: *fastRetryPolicy(3), failures: 2, err: transient, wantAttempts: 3},
+		{name: "gives up after max attempts", policy: *fastRetryPolicy(3), failures: 5, err: transient, wantAttempts: 3, wantErr: true},
+		{name: "does not retry permanent errors", policy: *fastRetryPolicy(3), failures: 5, err: errors.New("failed to parse html"), wantAttempts: 1, wantErr: true},
+		{name: "retry disabled", policy: *fastRetryPolicy(1), failures: 5, err: transient, wantAttempts: 1, wantErr: true},
+		{
+			name:         "stops when budget is exhausted",
+			policy:       RetryPolicy{MaxAttempts: 10, InitialBackoff: 50 * time.Millisecond, Multiplier: 1, Budget: 80 * time.Millisecond},
+			failures:     10,
+			err:          transient,
+			wantAttempts: 2,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := tt.policy.Do(context.Background(), "test", func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("Do() made %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+// TestFetchRetriesTransientStatus 本地站点先返回5xx再返回200，检查获取按策略重试并统计请求次数
+func TestFetchRetriesTransientStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		codes    []int // 依次返回的错误状态码
+		wantHits int
+		wantErr  bool
+	}{
+		{name: "recovers after 5xx", codes: []int{http.StatusServiceUnavailable, http.StatusBadGateway}, wantHits: 3},
+		{name: "exhausts attempts", codes: []int{500, 500, 500, 500}, wantHits: 3, wantErr: true},
+		{name: "404 is not retried", codes: []int{http.StatusNotFound}, wantHits: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := newFakeSite(t)
+			site.fail(fakeGuideID, tt.codes...)
+			b := newTestBrowser(t, site, Options{Retry: fastRetryPolicy(3)})
+
+			tutorial, err := b.GetTutorial(context.Background(), fakeGuideID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTutorial() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tutorial.Title != "节点图基础" {
+				t.Errorf("GetTutorial() title = %q", tutorial.Title)
+			}
+			if hits := site.hitCount(fakeGuideID); hits != tt.wantHits {
+				t.Errorf("site received %d requests, want %d", hits, tt.wantHits)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// 浏览器健康检查相关常量
+const (
+	// DefaultHealthInterval 默认的浏览器健康检查间隔
+	DefaultHealthInterval = 30 * time.Second
+
+	// 健康检查单次ping的超时时间，超时视为浏览器卡死
+	healthPingTimeout = 10 * time.Second
+
+	// 关闭浏览器的超时时间，卡死的浏览器超时后直接结束进程
+	browserCloseTimeout = 5 * time.Second
+)
+
+// BrowserStatus 浏览器运行状态
+type BrowserStatus struct {
+	Healthy     bool      `json:"healthy"`
+	Remote      bool      `json:"remote"`                 // 是否连接的是远程Chromium
+	Restarts    int       `json:"restarts"`               // 自动重启次数
+	LastRestart time.Time `json:"last_restart,omitempty"` // 最近一次重启时间
+	LastError   string    `json:"last_error,omitempty"`   // 最近一次导致重启的错误
+}
+
+// RodFetcher 基于rod驱动Chromium的页面获取器，同时打开的页面数受页面池限制。
+// 后台定期检查浏览器状态，浏览器退出、卡死或连接断开时自动重新启动。
+type RodFetcher struct {
+	chrome ChromeOptions
+	slots  chan struct{} // 页面池名额
+
+	mu         sync.RWMutex
+	session    *chromeSession
+	generation int  // 每次重启加一，避免并发失败触发重复重启
+	restarting bool // 正在重启，此时其它调用触发的重启直接返回
+	status     BrowserStatus
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRodFetcher 连接或启动Chromium并创建页面获取器，maxPages<=0时使用默认值，
+// healthInterval<=0时不做定期健康检查，仅在打开页面失败时重启
+func NewRodFetcher(maxPages int, chrome ChromeOptions, healthInterval time.Duration) (*RodFetcher, error) {
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+
+	session, err := openChromeSession(chrome)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &RodFetcher{
+		chrome:  chrome,
+		slots:   make(chan struct{}, maxPages),
+		session: session,
+		status: BrowserStatus{
+			Healthy: true,
+			Remote:  session.remote,
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	if healthInterval > 0 {
+		go f.superviseLoop(healthInterval)
+	} else {
+		close(f.done)
+	}
+
+	return f, nil
+}
+
+// openChromeSession 连接Chromium并完成初始化设置
+func openChromeSession(chrome ChromeOptions) (*chromeSession, error) {
+	session, err := connectChrome(chrome)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBrowserUnavailable, err)
+	}
+
+	// 共享的远程实例不修改全局设置
+	if !session.remote {
+		if err := session.browser.IgnoreCertErrors(true); err != nil {
+			session.close(chrome.UserDataDir != "")
+			return nil, fmt.Errorf("%w: failed to configure chromium: %w", ErrBrowserUnavailable, err)
+		}
+	}
+
+	return session, nil
+}
+
+// current 返回当前的浏览器实例及其代次
+func (f *RodFetcher) current() (*rod.Browser, int) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.session.browser, f.generation
+}
+
+// Status 返回浏览器运行状态
+func (f *RodFetcher) Status() BrowserStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.status
+}
+
+// superviseLoop 定期ping浏览器，失败时重启
+func (f *RodFetcher) superviseLoop(interval time.Duration) {
+	defer close(f.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+		}
+
+		browser, generation := f.current()
+		if _, err := browser.Timeout(healthPingTimeout).Version(); err != nil {
+			utils.Error("Browser health check failed", "generation", generation, "error", err)
+			f.restart(generation, err)
+		}
+	}
+}
+
+// restart 重启浏览器；generation 与当前代次不一致时说明已被其它调用重启过，正在重启时也直接返回。
+// 关闭卡死的旧实例和启动新实例都可能耗时较长，期间不持有锁，状态查询和其它调用不会被阻塞
+func (f *RodFetcher) restart(generation int, cause error) error {
+	f.mu.Lock()
+	if generation != f.generation || f.restarting {
+		f.mu.Unlock()
+		return nil
+	}
+
+	select {
+	case <-f.stop:
+		f.mu.Unlock()
+		return fmt.Errorf("%w: fetcher closed", ErrBrowserUnavailable)
+	default:
+	}
+
+	f.restarting = true
+	f.status.Healthy = false
+	f.status.LastError = cause.Error()
+	old := f.session
+	f.mu.Unlock()
+
+	// 旧实例可能已经退出或卡死，关闭失败不影响重启
+	if err := old.close(f.chrome.UserDataDir != ""); err != nil {
+		utils.Debug("Failed to close dead browser", "error", err)
+	}
+
+	session, err := openChromeSession(f.chrome)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.restarting = false
+	if err != nil {
+		utils.Error("Failed to restart browser", "restarts", f.status.Restarts, "error", err)
+		return err
+	}
+
+	// 重启期间获取器已关闭时不再使用新实例
+	select {
+	case <-f.stop:
+		session.close(f.chrome.UserDataDir != "")
+		return fmt.Errorf("%w: fetcher closed", ErrBrowserUnavailable)
+	default:
+	}
+
+	f.session = session
+	f.generation++
+	f.status.Healthy = true
+	f.status.Remote = session.remote
+	f.status.Restarts++
+	f.status.LastRestart = time.Now()
+
+	utils.Info("Browser restarted", "restarts", f.status.Restarts, "remote", session.remote, "cause", cause)
+	return nil
+}
+
+// acquire 占用一个页面池名额，名额用尽时排队等待，ctx取消时放弃等待
+func (f *RodFetcher) acquire(ctx context.Context) error {
+	select {
+	case f.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	utils.Debug("Page pool exhausted, waiting for a free page", "max_pages", cap(f.slots))
+	start := time.Now()
+	select {
+	case f.slots <- struct{}{}:
+		utils.Debug("Acquired page after waiting", "waited", time.Since(start))
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release 归还一个页面池名额
+func (f *RodFetcher) release() {
+	<-f.slots
+}
+
+// NewPage 创建绑定到ctx的新页面，ctx取消时导航和元素等待立即中止，使用完毕后需调用 ClosePage 归还名额
+func (f *RodFetcher) NewPage(ctx context.Context, url string) (*rod.Page, error) {
+	if err := f.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	browser, generation := f.current()
+	page, err := browser.Context(ctx).Page(proto.TargetCreateTarget{})
+	if err != nil {
+		f.release()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		// 无法打开页面通常意味着浏览器已退出或连接断开，重启后交给重试策略再次尝试
+		utils.Error("Failed to open page, restarting browser", "generation", generation, "error", err)
+		if restartErr := f.restart(generation, err); restartErr != nil {
+			return nil, fmt.Errorf("%w: failed to open page: %w", ErrBrowserUnavailable, err)
+		}
+		return nil, fmt.Errorf("%w: failed to open page, browser restarted: %w", ErrBrowserUnavailable, err)
+	}
+
+	// 导航到URL
+	err = page.Timeout(pageLoadTimeout).Navigate(url)
+	if err != nil {
+		f.ClosePage(page)
+		return nil, navigationError(ctx, url, err)
+	}
+
+	// 等待网络空闲
+	if err := page.WaitIdle(time.Minute); err != nil {
+		f.ClosePage(page)
+		return nil, navigationError(ctx, url, err)
+	}
+
+	return page, nil
+}
+
+// Pages 返回当前浏览器中打开的所有页面
+func (f *RodFetcher) Pages() (rod.Pages, error) {
+	browser, _ := f.current()
+	return browser.Pages()
+}
+
+// Fetch 打开页面，等待正文和导航渲染完成后返回完整的DOM
+func (f *RodFetcher) Fetch(ctx context.Context, url string) (string, error) {
+	page, err := f.NewPage(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer f.ClosePage(page)
+
+	// 等待页面加载完成
+	if err := page.Timeout(pageLoadTimeout).WaitLoad(); err != nil {
+		return "", navigationError(ctx, url, err)
+	}
+
+	// 等待主要内容区域加载，找不到时继续，由解析阶段处理
+	if _, err := page.Timeout(pageLoadTimeout).Element(".doc-view"); err != nil {
+		if ctx.Err() != nil {
+			return "", selectorError(ctx, ".doc-view", url, err)
+		}
+		utils.Debug("Main content not found", "url", url, "error", err)
+	}
+
+	// 等待导航菜单容器出现
+	if _, err := page.Timeout(quickElementTimeout).Element(".tw-scrollbar"); err != nil {
+		if ctx.Err() != nil {
+			return "", selectorError(ctx, ".tw-scrollbar", url, err)
+		}
+		utils.Debug("Navigation scrollbar not found", "url", url, "error", err)
+	}
+
+	html, err := page.HTML()
+	if err != nil {
+		return "", navigationError(ctx, url, err)
+	}
+	return html, nil
+}
+
+// ClosePage 关闭页面并归还页面池名额，即使页面绑定的ctx已取消也能关闭
+func (f *RodFetcher) ClosePage(page *rod.Page) {
+	if err := page.Context(context.Background()).Close(); err != nil {
+		utils.Debug("Failed to close page", "error", err)
+	}
+	f.release()
+}
+
+// Close 停止健康检查并关闭浏览器，连接远程Chromium时只释放本实例的浏览器上下文
+func (f *RodFetcher) Close() error {
+	close(f.stop)
+	<-f.done
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.session.close(f.chrome.UserDataDir != "")
+}
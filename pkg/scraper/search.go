@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
 	"genshin-starcraft-mcp/pkg/models"
 	"genshin-starcraft-mcp/pkg/utils"
 )
@@ -37,44 +38,60 @@ const (
 )
 
 // Search 执行搜索，返回搜索会话ID和结果
-func (b *Browser) Search(query string) (string, []models.SearchResult, error) {
+func (b *Browser) Search(ctx context.Context, query string) (string, []models.SearchResult, error) {
 	utils.Debug("Starting search", "query", query)
 
 	// 生成唯一的搜索会话ID
 	searchID := fmt.Sprintf("search_%d", time.Now().UnixNano())
 
 	// 导航到搜索页面
-	page, err := b.NewPage("https://act.mihoyo.com/ys/ugc/tutorial/detail/mh29wpicgvh0")
+	pageURL := b.TutorialURL(navigationPageID)
+	page, err := b.NewPage(ctx, pageURL)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to create search page: %w", err)
 	}
-	defer page.Close()
+	defer b.ClosePage(page)
 
 	// 等待页面加载完成
-	page.MustWaitLoad()
+	if err := page.Timeout(pageLoadTimeout).WaitLoad(); err != nil {
+		return "", nil, navigationError(ctx, pageURL, err)
+	}
 
 	// 等待页面完全加载并额外等待
-	time.Sleep(initialDelay)
+	if err := sleepContext(ctx, initialDelay); err != nil {
+		return "", nil, err
+	}
 
 	// 等待搜索框出现，增加超时时间
 	searchBox, err := page.Timeout(searchBoxTimeout).Element("input[type=search]")
 	if err != nil {
-		return "", nil, fmt.Errorf("search box not found: %w", err)
+		return "", nil, selectorError(ctx, "input[type=search]", pageURL, err)
 	}
 
 	utils.Debug("Found search box, entering query")
 
 	// 清空搜索框并输入查询
-	searchBox.MustSelectAllText().MustInput(query)
+	if err := searchBox.SelectAllText(); err != nil {
+		return "", nil, fmt.Errorf("failed to select search box text: %w", err)
+	}
+	if err := searchBox.Input(query); err != nil {
+		return "", nil, fmt.Errorf("failed to input search query: %w", err)
+	}
 
 	// 等待一下再按回车
-	time.Sleep(shortDelay)
+	if err := sleepContext(ctx, shortDelay); err != nil {
+		return "", nil, err
+	}
 
 	// 按回车键
-	searchBox.MustKeyActions().Press(input.Enter)
+	if err := pressEnter(searchBox); err != nil {
+		return "", nil, err
+	}
 
 	// 等待对话框出现，增加超时时间和额外等待
-	time.Sleep(mediumDelay) // 等待弹窗出现
+	if err := sleepContext(ctx, mediumDelay); err != nil { // 等待弹窗出现
+		return "", nil, err
+	}
 
 	dialogElement, err := page.Timeout(searchDialogTimeout).Element("div[role=dialog]")
 	if err != nil {
@@ -97,23 +114,21 @@ func (b *Browser) Search(query string) (string, []models.SearchResult, error) {
 		}
 
 		if dialogElement == nil {
-			return "", nil, fmt.Errorf("search dialog not found with any selector: %w", err)
+			return "", nil, selectorError(ctx, "div[role=dialog]", pageURL, err)
 		}
 	}
 
 	utils.Debug("Found search dialog")
 
 	// 等待结果加载，增加等待时间
-	time.Sleep(shortDelay)
-
-	// 设置超时上下文获取搜索结果
-	resultCtx, resultCancel := context.WithTimeout(context.Background(), quickElementTimeout)
-	defer resultCancel()
+	if err := sleepContext(ctx, shortDelay); err != nil {
+		return "", nil, err
+	}
 
-	// 获取搜索结果
-	resultElements, err := dialogElement.Context(resultCtx).Elements("a.tw-relative.tw-block")
+	// 设置超时获取搜索结果
+	resultElements, err := dialogElement.Timeout(quickElementTimeout).Elements("a.tw-relative.tw-block")
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to find result elements: %w", err)
+		return "", nil, selectorError(ctx, "a.tw-relative.tw-block", pageURL, err)
 	}
 
 	utils.Debug("Found result elements", "count", len(resultElements))
@@ -127,7 +142,11 @@ func (b *Browser) Search(query string) (string, []models.SearchResult, error) {
 			utils.Debug("Failed to get title element", "index", i, "error", err)
 			continue
 		}
-		title := strings.TrimSpace(titleElement.MustText())
+		title, err := elementTextOf(titleElement)
+		if err != nil {
+			utils.Debug("Failed to get title text", "index", i, "error", err)
+			continue
+		}
 
 		// 获取描述 - 使用最后一个div作为描述
 		descElement, err := element.Timeout(quickElementTimeout).Element("div > div > div:last-child")
@@ -135,7 +154,11 @@ func (b *Browser) Search(query string) (string, []models.SearchResult, error) {
 			utils.Debug("Failed to get description element", "index", i, "error", err)
 			continue
 		}
-		description := strings.TrimSpace(descElement.MustText())
+		description, err := elementTextOf(descElement)
+		if err != nil {
+			utils.Debug("Failed to get description text", "index", i, "error", err)
+			continue
+		}
 
 		// 搜索结果包含搜索会话ID和结果索引
 		result := models.SearchResult{
@@ -155,44 +178,53 @@ func (b *Browser) Search(query string) (string, []models.SearchResult, error) {
 
 
 // OpenSearchResultByTitle 根据标题匹配并打开搜索结果，返回页面内容
-func (b *Browser) OpenSearchResultByTitle(title string) (string, error) {
+func (b *Browser) OpenSearchResultByTitle(ctx context.Context, title string) (string, error) {
 	utils.Debug("Opening search result by title", "title", title)
 
 	// 导航到教程页面
-	page, err := b.NewPage("https://act.mihoyo.com/ys/ugc/tutorial/detail/mh29wpicgvh0")
+	pageURL := b.TutorialURL(navigationPageID)
+	page, err := b.NewPage(ctx, pageURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to create search page: %w", err)
 	}
-	defer page.Close()
+	defer b.ClosePage(page)
 
 	// 等待页面加载完成
-	page.MustWaitLoad()
+	if err := page.Timeout(pageLoadTimeout).WaitLoad(); err != nil {
+		return "", navigationError(ctx, pageURL, err)
+	}
 
 	// 等待搜索框出现
 	searchBox, err := b.WaitForElement(page, "input[type=search]", searchBoxTimeout)
 	if err != nil {
-		return "", fmt.Errorf("search box not found: %w", err)
+		return "", selectorError(ctx, "input[type=search]", pageURL, err)
 	}
 
 	// 输入一个通用搜索词触发搜索弹窗
-	searchBox.MustInput("教程")
+	if err := searchBox.Input("教程"); err != nil {
+		return "", fmt.Errorf("failed to input search query: %w", err)
+	}
 
 	// 按回车键
-	searchBox.MustKeyActions().Press(input.Enter)
+	if err := pressEnter(searchBox); err != nil {
+		return "", err
+	}
 
 	// 等待对话框出现
 	dialogElement, err := b.WaitForElement(page, "div[role=dialog]", quickElementTimeout)
 	if err != nil {
-		return "", fmt.Errorf("search dialog not found: %w", err)
+		return "", selectorError(ctx, "div[role=dialog]", pageURL, err)
 	}
 
 	// 等待结果加载
-	page.MustWaitIdle()
+	if err := page.WaitIdle(time.Minute); err != nil {
+		return "", navigationError(ctx, pageURL, err)
+	}
 
 	// 获取搜索结果
 	resultElements, err := dialogElement.Elements("a.tw-relative.tw-block")
 	if err != nil {
-		return "", fmt.Errorf("failed to find result elements: %w", err)
+		return "", selectorError(ctx, "a.tw-relative.tw-block", pageURL, err)
 	}
 
 	// 遍历搜索结果，匹配标题
@@ -203,25 +235,31 @@ func (b *Browser) OpenSearchResultByTitle(title string) (string, error) {
 			utils.Debug("Failed to get title element", "index", i, "error", err)
 			continue
 		}
-		currentTitle := strings.TrimSpace(titleElement.MustText())
+		currentTitle, err := elementTextOf(titleElement)
+		if err != nil {
+			utils.Debug("Failed to get title text", "index", i, "error", err)
+			continue
+		}
 
 		// 如果标题匹配，点击这个结果
 		if currentTitle == title {
 			utils.Debug("Found matching result", "index", i, "title", currentTitle)
 
 			// 点击匹配的搜索结果
-			element.MustClick()
+			if err := element.Click(proto.InputMouseButtonLeft, 1); err != nil {
+				return "", fmt.Errorf("failed to click search result %s: %w", title, err)
+			}
 
 			// 获取新打开页面的内容
-			pages, err := b.browser.Pages()
+			pages, err := b.rod.Pages()
 			if err != nil {
-				return "", fmt.Errorf("failed to get pages: %w", err)
+				return "", fmt.Errorf("%w: failed to get pages: %w", ErrBrowserUnavailable, err)
 			}
 
 			for _, p := range pages {
 				if p != page {
 					// 获取页面内容
-					return b.getPageContent(p, title)
+					return b.getPageContent(ctx, p.Context(ctx), title)
 				}
 			}
 
@@ -233,16 +271,20 @@ func (b *Browser) OpenSearchResultByTitle(title string) (string, error) {
 }
 
 // getPageContent 获取页面内容
-func (b *Browser) getPageContent(page *rod.Page, title string) (string, error) {
+func (b *Browser) getPageContent(ctx context.Context, page *rod.Page, title string) (string, error) {
+	defer page.Context(context.Background()).Close()
+
 	// 等待页面加载完成
-	page.MustWaitLoad()
+	if err := page.Timeout(pageLoadTimeout).WaitLoad(); err != nil {
+		return "", navigationError(ctx, title, err)
+	}
 
 	// 获取页面标题
 	pageTitleElement, err := page.Timeout(elementTimeout).Element("h1")
 	if err != nil {
 		utils.Debug("Failed to find page title, using provided title")
 	} else {
-		pageTitle := strings.TrimSpace(pageTitleElement.MustText())
+		pageTitle, _ := elementTextOf(pageTitleElement)
 		utils.Debug("Page title", "title", pageTitle)
 	}
 
@@ -259,17 +301,49 @@ func (b *Browser) getPageContent(page *rod.Page, title string) (string, error) {
 	}
 
 	if contentElement == nil {
-		return "", fmt.Errorf("failed to find content with any selector")
+		return "", selectorError(ctx, strings.Join(selectors, ", "), title, err)
 	}
 
-	content := strings.TrimSpace(contentElement.MustText())
-
-	// 关闭页面
-	page.Close()
+	content, err := elementTextOf(contentElement)
+	if err != nil {
+		return "", fmt.Errorf("failed to read content of %s: %w", title, err)
+	}
 
 	// 返回格式化的内容
 	result := fmt.Sprintf("# %s\n\n%s", title, content)
 	return result, nil
 }
 
+// elementTextOf 获取rod元素去除首尾空白的文本
+func elementTextOf(element *rod.Element) (string, error) {
+	text, err := element.Text()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// pressEnter 在元素上按下回车键
+func pressEnter(element *rod.Element) error {
+	actions, err := element.KeyActions()
+	if err != nil {
+		return fmt.Errorf("failed to focus element: %w", err)
+	}
+	if err := actions.Press(input.Enter).Do(); err != nil {
+		return fmt.Errorf("failed to press enter: %w", err)
+	}
+	return nil
+}
+
+// sleepContext 等待指定时间，ctx取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
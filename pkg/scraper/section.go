@@ -0,0 +1,325 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"genshin-starcraft-mcp/pkg/models"
+)
+
+// 章节解析相关常量
+const (
+	// 第一个标题之前的正文所在章节的ID
+	introSectionID = "intro"
+
+	// 摘要的最大字符数
+	summaryMaxRunes = 200
+)
+
+// sectionNode 构建章节树时的中间节点，记录章节自身（不含子章节）的DOM节点
+type sectionNode struct {
+	section  models.Section
+	nodes    []*html.Node
+	children []*sectionNode
+}
+
+// parseSections 按标题层级将正文解析为章节树。每个章节只包含自身的Markdown正文和表格，
+// 子章节的内容位于 Children 中；第一个标题之前的正文归入ID为 intro 的章节
+func parseSections(content *goquery.Selection, conv *markdownConverter, title string) []models.Section {
+	intro := &sectionNode{section: models.Section{ID: introSectionID, Title: title}}
+	var roots []*sectionNode
+	var stack []*sectionNode
+	// intro 保留给第一个标题之前的正文，标题 Intro 的ID为 intro-2
+	ids := map[string]bool{introSectionID: true}
+
+	for _, root := range content.Nodes {
+		flattenSections(root, func(node *html.Node) {
+			if level := headingLevel(node); level > 0 {
+				text := strings.ReplaceAll(nodesText([]*html.Node{node}), "\n", " ")
+				if text == "" {
+					return
+				}
+
+				current := &sectionNode{section: models.Section{
+					ID:    sectionID(text, ids),
+					Title: text,
+					Level: level,
+				}}
+
+				for len(stack) > 0 && stack[len(stack)-1].section.Level >= level {
+					stack = stack[:len(stack)-1]
+				}
+				if len(stack) == 0 {
+					roots = append(roots, current)
+				} else {
+					parent := stack[len(stack)-1]
+					parent.children = append(parent.children, current)
+				}
+				stack = append(stack, current)
+				return
+			}
+
+			if len(stack) == 0 {
+				intro.nodes = append(intro.nodes, node)
+				return
+			}
+			top := stack[len(stack)-1]
+			top.nodes = append(top.nodes, node)
+		})
+	}
+
+	var sections []models.Section
+	if section := intro.build(conv); section.Content != "" || len(section.Tables) > 0 {
+		sections = append(sections, section)
+	}
+	for _, root := range roots {
+		sections = append(sections, root.build(conv))
+	}
+
+	return sections
+}
+
+// build 渲染章节正文、提取表格并递归构建子章节
+func (n *sectionNode) build(conv *markdownConverter) models.Section {
+	section := n.section
+	section.Content = strings.Join(conv.renderNodes(n.nodes), "\n\n")
+
+	for _, node := range n.nodes {
+		for _, table := range findTables(node) {
+			if t, ok := parseTableModel(table); ok {
+				section.Tables = append(section.Tables, t)
+			}
+		}
+	}
+
+	for _, child := range n.children {
+		section.Children = append(section.Children, child.build(conv))
+	}
+
+	return section
+}
+
+// flattenSections 按文档顺序展开正文：标题和不含标题的元素原样输出，包含标题的容器继续向下展开
+func flattenSections(node *html.Node, emit func(*html.Node)) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if headingLevel(child) > 0 || !containsHeading(child) {
+			emit(child)
+			continue
+		}
+		flattenSections(child, emit)
+	}
+}
+
+// headingLevel 返回h1-h6的标题级别，非标题返回0
+func headingLevel(node *html.Node) int {
+	if node.Type != html.ElementNode || len(node.Data) != 2 || node.Data[0] != 'h' {
+		return 0
+	}
+	if level := int(node.Data[1] - '0'); level >= 1 && level <= 6 {
+		return level
+	}
+	return 0
+}
+
+// containsHeading 判断节点的后代中是否有标题
+func containsHeading(node *html.Node) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if headingLevel(child) > 0 || containsHeading(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionID 根据标题文本生成稳定的章节ID：保留字母、数字和汉字，其余字符合并为连字符；
+// 同一教程内重名的标题依次追加 -2、-3，跳过已被其它标题使用的ID，seen 记录已使用的ID
+func sectionID(title string, seen map[string]bool) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+
+	base := sb.String()
+	if base == "" {
+		base = "section"
+	}
+
+	id := base
+	for n := 2; seen[id]; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	seen[id] = true
+	return id
+}
+
+// findTables 查找节点自身或后代中的表格，不包含嵌套在表格中的表格
+func findTables(node *html.Node) []*html.Node {
+	if node.Type != html.ElementNode {
+		return nil
+	}
+	if node.Data == "table" {
+		return []*html.Node{node}
+	}
+
+	var tables []*html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		tables = append(tables, findTables(child)...)
+	}
+	return tables
+}
+
+// parseTableModel 将表格转换为 models.Table，首行作为表头，合并单元格展开为重复内容
+func parseTableModel(node *html.Node) (models.Table, bool) {
+	grid := tableGrid(node, func(cell *html.Node) string {
+		return nodesText([]*html.Node{cell})
+	})
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		return models.Table{}, false
+	}
+
+	// 补齐各行列数，与Markdown表格保持一致
+	width := 0
+	for _, cells := range grid {
+		width = max(width, len(cells))
+	}
+	for i, cells := range grid {
+		for len(cells) < width {
+			cells = append(cells, "")
+		}
+		grid[i] = cells
+	}
+
+	table := models.Table{
+		Headers: grid[0],
+		Rows:    grid[1:],
+	}
+	if caption := findChild(node, "caption"); caption != nil {
+		table.Caption = nodesText([]*html.Node{caption})
+	}
+
+	return table, true
+}
+
+// outlineOf 返回只包含ID、标题和层级的章节树
+func outlineOf(sections []models.Section) []models.Section {
+	outline := make([]models.Section, 0, len(sections))
+	for _, section := range sections {
+		outline = append(outline, models.Section{
+			ID:       section.ID,
+			Title:    section.Title,
+			Level:    section.Level,
+			Children: outlineOf(section.Children),
+		})
+	}
+	return outline
+}
+
+// summarize 抽取正文开头的段落句子作为摘要，不超过 summaryMaxRunes 个字符
+func summarize(content *goquery.Selection) string {
+	var sb strings.Builder
+
+	for _, paragraph := range content.Find("p").EachIter() {
+		for _, sentence := range splitSentences(elementText(paragraph)) {
+			if utf8.RuneCountInString(sb.String())+utf8.RuneCountInString(sentence) > summaryMaxRunes {
+				if sb.Len() == 0 {
+					// 第一句就超长时截断
+					return string([]rune(sentence)[:summaryMaxRunes]) + "…"
+				}
+				return sb.String()
+			}
+			sb.WriteString(sentence)
+		}
+	}
+
+	return sb.String()
+}
+
+// splitSentences 按中英文句末标点切分句子，保留标点
+func splitSentences(text string) []string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	var sentences []string
+	start := 0
+	for i, r := range text {
+		switch r {
+		case '。', '！', '？', '；', '!', '?', ';':
+			end := i + utf8.RuneLen(r)
+			if sentence := strings.TrimSpace(text[start:end]); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start = end
+		}
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		// 没有结尾标点的段落补上句号，避免与下一段连在一起
+		if last, _ := utf8.DecodeLastRuneInString(rest); !unicode.IsPunct(last) {
+			rest += "。"
+		}
+		sentences = append(sentences, rest)
+	}
+
+	return sentences
+}
+
+// GetTutorialSection 获取教程中的单个章节，section 可以是章节ID或标题
+func (b *Browser) GetTutorialSection(ctx context.Context, id string, section string) (*models.Tutorial, *models.Section, error) {
+	tutorial, err := b.GetTutorial(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := FindSection(tutorial.Sections, section)
+	if found == nil {
+		return tutorial, nil, fmt.Errorf("%w: '%s' in tutorial %s", ErrSectionNotFound, section, id)
+	}
+
+	return tutorial, found, nil
+}
+
+// FindSection 在章节树中查找章节：依次按ID精确匹配、标题精确匹配、标题包含匹配（忽略大小写和首尾空白）
+func FindSection(sections []models.Section, query string) *models.Section {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	lower := strings.ToLower(query)
+
+	matchers := []func(*models.Section) bool{
+		func(s *models.Section) bool { return s.ID == query },
+		func(s *models.Section) bool { return strings.EqualFold(s.Title, query) },
+		func(s *models.Section) bool { return strings.Contains(strings.ToLower(s.Title), lower) },
+	}
+	for _, match := range matchers {
+		if found := findSectionBy(sections, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findSectionBy 深度优先查找第一个满足条件的章节
+func findSectionBy(sections []models.Section, match func(*models.Section) bool) *models.Section {
+	for i := range sections {
+		if match(&sections[i]) {
+			return &sections[i]
+		}
+		if found := findSectionBy(sections[i].Children, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
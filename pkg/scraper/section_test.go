@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestSectionID(t *testing.T) {
+	tests := []struct {
+		name   string
+		titles []string
+		want   []string
+	}{
+		{name: "slug", titles: []string{"Getting Started!", "节点图 基础"}, want: []string{"getting-started", "节点图-基础"}},
+		{name: "duplicates", titles: []string{"A", "A", "A"}, want: []string{"a", "a-2", "a-3"}},
+		{name: "duplicate collides with natural slug", titles: []string{"A", "A", "A 2"}, want: []string{"a", "a-2", "a-2-2"}},
+		{name: "natural slug first", titles: []string{"A 2", "A", "A"}, want: []string{"a-2", "a", "a-3"}},
+		{name: "empty", titles: []string{"!!", "??"}, want: []string{"section", "section-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := map[string]bool{}
+			var got []string
+			for _, title := range tt.titles {
+				got = append(got, sectionID(title, seen))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sectionID(%v) = %v, want %v", tt.titles, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFindSectionByGeneratedID 重名标题和与 intro 同名的标题生成的ID都能找到对应的章节
+func TestFindSectionByGeneratedID(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div class="doc-view"><p>lead</p>
+<h2>A</h2><p>first</p><h2>A</h2><p>second</p><h2>A 2</h2><p>third</p><h2>Intro</h2><p>fourth</p></div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := doc.Find(".doc-view")
+	sections := parseSections(content, newMarkdownConverter(DefaultBaseURL), "title")
+
+	for id, want := range map[string]string{"intro": "lead", "a": "first", "a-2": "second", "a-2-2": "third", "intro-2": "fourth"} {
+		section := FindSection(sections, id)
+		if section == nil {
+			t.Errorf("FindSection(%q) = nil", id)
+			continue
+		}
+		if section.Content != want {
+			t.Errorf("FindSection(%q).Content = %q, want %q", id, section.Content, want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/models"
+)
+
+// 快照目录相关常量
+const (
+	// SnapshotSchemaVersion 快照文件格式版本，格式不兼容地变化时递增
+	SnapshotSchemaVersion = 1
+
+	// DefaultSnapshotDir 默认的快照根目录
+	DefaultSnapshotDir = "snapshots"
+
+	// 快照版本名的时间格式，按字典序排列即为时间顺序
+	snapshotVersionLayout = "20060102-150405"
+
+	// 快照目录中的文件
+	snapshotManifestFile   = "manifest.json"
+	snapshotNavigationFile = "navigation.json"
+	snapshotGuidesDir      = "guides"
+	snapshotNodesDir       = "nodes"
+)
+
+// SnapshotEntry 快照中的一个页面文件
+type SnapshotEntry struct {
+	Kind       string    `json:"kind"`                  // 页面种类：navigation、tutorial、node_graph
+	ID         string    `json:"id"`                    // 页面ID
+	Title      string    `json:"title,omitempty"`       // 导航目录中的标题
+	ClientType string    `json:"client_type,omitempty"` // 仅节点类型页面
+	NodeType   string    `json:"node_type,omitempty"`   // 仅节点类型页面
+	Path       string    `json:"path"`                  // 相对快照目录的文件路径
+	SHA256     string    `json:"sha256,omitempty"`      // 文件内容的哈希
+	Size       int       `json:"size,omitempty"`        // 文件字节数
+	FetchedAt  time.Time `json:"fetched_at"`
+	Error      string    `json:"error,omitempty"` // 获取失败的原因，续抓时会重试
+}
+
+// SnapshotManifest 快照清单，记录每个页面文件的哈希和获取时间
+type SnapshotManifest struct {
+	SchemaVersion int             `json:"schema_version"`
+	Version       string          `json:"version"` // 快照版本名，即快照目录名
+	BaseURL       string          `json:"base_url"`
+	StartedAt     time.Time       `json:"started_at"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"` // 所有页面都成功获取的时间
+	FinishedAt    *time.Time      `json:"finished_at,omitempty"`  // 抓取结束的时间，有页面获取失败时也记录，中断的抓取为空
+	Complete      bool            `json:"complete"`               // 所有页面都已成功获取
+	Entries       []SnapshotEntry `json:"entries"`                // 按文件路径排序
+}
+
+// Failed 返回获取失败的页面数
+func (m *SnapshotManifest) Failed() int {
+	failed := 0
+	for _, entry := range m.Entries {
+		if entry.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Comparable 判断快照能否用于比较变化：所有页面都已成功获取，或抓取已结束且导航目录获取成功。
+// 获取失败的页面在比较时跳过；中断的抓取缺少未获取的页面，会被误报为移除，不能用于比较
+func (m *SnapshotManifest) Comparable() bool {
+	if m.Complete {
+		return true
+	}
+	if m.FinishedAt == nil {
+		return false
+	}
+	for _, entry := range m.Entries {
+		if entry.Path == snapshotNavigationFile {
+			return entry.Error == ""
+		}
+	}
+	return false
+}
+
+// Snapshot 磁盘上的一个快照目录
+type Snapshot struct {
+	Dir      string
+	Manifest *SnapshotManifest
+
+	files map[string][]byte // 内嵌数据集中的文件，按相对路径索引；为nil时从 Dir 读取
+}
+
+// OpenSnapshot 打开快照目录并读取清单
+func OpenSnapshot(dir string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot manifest: %w", err)
+	}
+
+	var manifest SnapshotManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot manifest %s: %w", dir, err)
+	}
+	if manifest.SchemaVersion != SnapshotSchemaVersion {
+		return nil, fmt.Errorf("snapshot %s has schema version %d, expected %d", dir, manifest.SchemaVersion, SnapshotSchemaVersion)
+	}
+
+	return &Snapshot{Dir: dir, Manifest: &manifest}, nil
+}
+
+// ListSnapshots 返回根目录下所有可读取的快照，按版本名从旧到新排序
+func ListSnapshots(root string) ([]*Snapshot, error) {
+	dirEntries, err := os.ReadDir(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshots []*Snapshot
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		snapshot, err := OpenSnapshot(filepath.Join(root, dirEntry.Name()))
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	slices.SortFunc(snapshots, func(a, b *Snapshot) int {
+		return strings.Compare(a.Manifest.Version, b.Manifest.Version)
+	})
+	return snapshots, nil
+}
+
+// Entry 按文件路径查找清单中的条目
+func (s *Snapshot) Entry(path string) (*SnapshotEntry, bool) {
+	i, found := slices.BinarySearchFunc(s.Manifest.Entries, path, func(entry SnapshotEntry, path string) int {
+		return strings.Compare(entry.Path, path)
+	})
+	if !found {
+		return nil, false
+	}
+	return &s.Manifest.Entries[i], true
+}
+
+// MissingCorePages 返回快照中缺失或获取失败的核心页面：导航目录，以及导航目录中列出的和清单中记录的节点类型页面。
+// 核心页面不全的快照不能作为内嵌数据集发布；教程获取失败只影响单个教程，不在检查范围内
+func (s *Snapshot) MissingCorePages() []string {
+	if err := s.servedEntry(snapshotNavigationFile, "navigation"); err != nil {
+		return []string{PageKindNavigation}
+	}
+	items, err := s.Navigation()
+	if err != nil {
+		return []string{PageKindNavigation}
+	}
+
+	missing := map[string]bool{}
+	nodeTypes := nodeTypesFromNavigation(items)
+	for _, clientType := range nodeTypes.ClientTypes() {
+		for _, nodeType := range nodeTypes.Types(clientType) {
+			if entry, ok := s.Entry(snapshotNodePath(nodeTypes[clientType][nodeType])); !ok || entry.Error != "" {
+				missing[nodeGraphPageName(clientType, nodeType)] = true
+			}
+		}
+	}
+	for _, entry := range s.Manifest.Entries {
+		if entry.Kind == PageKindNodeGraph && entry.Error != "" {
+			missing[nodeGraphPageName(entry.ClientType, entry.NodeType)] = true
+		}
+	}
+
+	return slices.Sorted(maps.Keys(missing))
+}
+
+// Navigation 读取快照中的导航目录
+func (s *Snapshot) Navigation() ([]models.NavigationItem, error) {
+	var items []models.NavigationItem
+	if err := s.read(snapshotNavigationFile, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Tutorial 读取快照中的教程，LastUpdated 为页面的获取时间
+func (s *Snapshot) Tutorial(id string) (*models.Tutorial, error) {
+	path := snapshotGuidePath(id)
+	var tutorial models.Tutorial
+	if err := s.read(path, &tutorial); err != nil {
+		return nil, err
+	}
+	if entry, ok := s.Entry(path); ok {
+		tutorial.LastUpdated = entry.FetchedAt
+	}
+	return &tutorial, nil
+}
+
+// NodeGraphPage 读取快照中的节点类型页面，LastUpdated 为页面的获取时间
+func (s *Snapshot) NodeGraphPage(id string) (*models.NodeGraphPage, error) {
+	path := snapshotNodePath(id)
+	var page models.NodeGraphPage
+	if err := s.read(path, &page); err != nil {
+		return nil, err
+	}
+	if entry, ok := s.Entry(path); ok {
+		page.LastUpdated = entry.FetchedAt
+		for _, node := range page.Nodes {
+			node.LastUpdated = entry.FetchedAt
+		}
+	}
+	return &page, nil
+}
+
+// read 读取快照中的JSON文件
+func (s *Snapshot) read(path string, v any) error {
+	data, err := s.readFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse snapshot file %s: %w", path, err)
+	}
+	return nil
+}
+
+// readFile 读取快照中文件的原始内容
+func (s *Snapshot) readFile(path string) ([]byte, error) {
+	if s.files == nil {
+		return os.ReadFile(filepath.Join(s.Dir, path))
+	}
+	data, ok := s.files[path]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+	}
+	return data, nil
+}
+
+// snapshotGuidePath 教程在快照中的文件路径
+func snapshotGuidePath(id string) string {
+	return snapshotGuidesDir + "/" + pageFileName(id) + ".json"
+}
+
+// snapshotNodePath 节点类型页面在快照中的文件路径
+func snapshotNodePath(id string) string {
+	return snapshotNodesDir + "/" + pageFileName(id) + ".json"
+}
+
+// encodeSnapshotFile 将页面编码为快照文件内容并计算哈希
+func encodeSnapshotFile(v any) ([]byte, string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	data = append(data, '\n')
+	return data, contentHash(data), nil
+}
+
+// contentHash 计算文件内容的SHA-256
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"genshin-starcraft-mcp/pkg/models"
+)
+
+func TestMissingCorePages(t *testing.T) {
+	navigation, err := json.Marshal([]models.NavigationItem{
+		{Title: "服务器执行节点", URL: fakeServerExecID},
+		{Title: "服务器查询节点", URL: fakeServerQueryID},
+		{Title: "客户端执行节点", URL: fakeClientExecID},
+		{Title: "节点图基础", URL: fakeGuideID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	navigationEntry := SnapshotEntry{Kind: PageKindNavigation, ID: navigationPageID, Path: snapshotNavigationFile}
+	nodeEntry := func(id string, clientType string, nodeType string, fetchErr string) SnapshotEntry {
+		return SnapshotEntry{Kind: PageKindNodeGraph, ID: id, ClientType: clientType, NodeType: nodeType, Path: snapshotNodePath(id), Error: fetchErr}
+	}
+
+	tests := []struct {
+		name    string
+		entries []SnapshotEntry
+		want    []string
+	}{
+		{
+			name: "all core pages present",
+			entries: []SnapshotEntry{
+				navigationEntry,
+				nodeEntry(fakeServerExecID, "服务器节点", "执行节点", ""),
+				nodeEntry(fakeServerQueryID, "服务器节点", "查询节点", ""),
+				nodeEntry(fakeClientExecID, "客户端节点", "执行节点", ""),
+				testGuideEntry(fakeGuideID, "节点图基础", "", "http 503"),
+			},
+		},
+		{
+			name:    "navigation failed",
+			entries: []SnapshotEntry{{Kind: PageKindNavigation, ID: navigationPageID, Path: snapshotNavigationFile, Error: "timeout"}},
+			want:    []string{PageKindNavigation},
+		},
+		{
+			name: "node type pages missing or failed",
+			entries: []SnapshotEntry{
+				navigationEntry,
+				nodeEntry(fakeServerExecID, "服务器节点", "执行节点", ""),
+				nodeEntry(fakeServerQueryID, "服务器节点", "查询节点", "http 500"),
+				// 内置映射中、导航目录未列出的节点类型页面获取失败也算缺失
+				nodeEntry("builtin00001", "服务器节点", "事件节点", "http 404"),
+			},
+			want: []string{"客户端节点/执行节点", "服务器节点/事件节点", "服务器节点/查询节点"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := slices.Clone(tt.entries)
+			slices.SortFunc(entries, func(a, b SnapshotEntry) int {
+				return strings.Compare(a.Path, b.Path)
+			})
+			snapshot := &Snapshot{
+				Manifest: &SnapshotManifest{Version: "test", Entries: entries},
+				files:    map[string][]byte{snapshotNavigationFile: navigation},
+			}
+			if got := snapshot.MissingCorePages(); !slices.Equal(got, tt.want) {
+				t.Errorf("MissingCorePages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+)
+
+// SnapshotSource 一次请求中来自快照而不是官方网站的内容的来源
+type SnapshotSource struct {
+	Manifest *SnapshotManifest // 内容所在快照的清单，Manifest.StartedAt 为抓取时间
+	Embedded bool              // 快照为构建时内嵌的数据集：实时获取失败或无法启动Chromium时使用
+}
+
+// ContentSource 记录一次请求中使用的快照，调用方据此提醒内容可能不是最新的
+type ContentSource struct {
+	mu     sync.Mutex
+	source *SnapshotSource
+}
+
+// contentSourceKey ctx中 ContentSource 的key
+type contentSourceKey struct{}
+
+// WithContentSource 返回记录内容来源的ctx，请求处理完成后用 ContentSourceFrom 读取
+func WithContentSource(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contentSourceKey{}, &ContentSource{})
+}
+
+// ContentSourceFrom 返回请求中使用的快照，内容都来自官方网站或缓存，或ctx不记录来源时返回nil
+func ContentSourceFrom(ctx context.Context) *SnapshotSource {
+	recorder, ok := ctx.Value(contentSourceKey{}).(*ContentSource)
+	if !ok {
+		return nil
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.source
+}
+
+// recordSnapshotSource 记录请求使用了快照中的内容
+func (b *Browser) recordSnapshotSource(ctx context.Context, snapshot *Snapshot) {
+	recorder, ok := ctx.Value(contentSourceKey{}).(*ContentSource)
+	if !ok {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.source = &SnapshotSource{Manifest: snapshot.Manifest, Embedded: snapshot == b.fallback}
+}
@@ -0,0 +1,62 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/models"
+)
+
+// TestContentSourceRecordsFallback 实时获取失败改用内嵌数据集时记录数据集的版本和抓取时间，实时获取成功时不记录
+func TestContentSourceRecordsFallback(t *testing.T) {
+	site := newFakeSite(t)
+	b := newTestBrowser(t, site, Options{})
+
+	data, err := json.Marshal(models.Tutorial{URL: fakeGuideID, Title: "节点图基础（数据集）"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := snapshotGuidePath(fakeGuideID)
+	crawledAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b.fallback = &Snapshot{
+		Dir: "embedded",
+		Manifest: &SnapshotManifest{
+			Version:   "20250101-120000",
+			StartedAt: crawledAt,
+			Entries:   []SnapshotEntry{{Kind: PageKindTutorial, ID: fakeGuideID, Path: path}},
+		},
+		files: map[string][]byte{path: data},
+	}
+
+	ctx := WithContentSource(context.Background())
+	if _, err := b.GetTutorial(ctx, fakeGuideID); err != nil {
+		t.Fatal(err)
+	}
+	if source := ContentSourceFrom(ctx); source != nil {
+		t.Errorf("live fetch recorded snapshot source %+v", source)
+	}
+
+	if _, err := b.InvalidateCache(PageKindTutorial, fakeGuideID); err != nil {
+		t.Fatal(err)
+	}
+	site.fail(fakeGuideID, http.StatusServiceUnavailable)
+
+	ctx = WithContentSource(context.Background())
+	tutorial, err := b.GetTutorial(ctx, fakeGuideID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tutorial.Title != "节点图基础（数据集）" {
+		t.Fatalf("GetTutorial returned %q, want the embedded dataset", tutorial.Title)
+	}
+	source := ContentSourceFrom(ctx)
+	if source == nil || !source.Embedded {
+		t.Fatalf("fallback not recorded as embedded dataset: %+v", source)
+	}
+	if source.Manifest.Version != "20250101-120000" || !source.Manifest.StartedAt.Equal(crawledAt) {
+		t.Errorf("recorded snapshot %s at %s", source.Manifest.Version, source.Manifest.StartedAt)
+	}
+}
@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"genshin-starcraft-mcp/pkg/utils"
+)
+
+// DefaultWarmUpConcurrency 预热时默认同时获取的页面数
+const DefaultWarmUpConcurrency = 2
+
+// WarmUp 预先获取导航目录和所有节点类型页面，写入缓存，已缓存的页面不会重新获取。
+// 最多同时获取 concurrency 个页面，单个页面失败只记录日志；只在ctx取消时返回错误
+func (b *Browser) WarmUp(ctx context.Context, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultWarmUpConcurrency
+	}
+
+	start := time.Now()
+	utils.Info("Cache warm-up started", "concurrency", concurrency)
+
+	if _, err := b.GetNavigation(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		utils.Error("Cache warm-up failed to load navigation", "error", err)
+	} else {
+		utils.Info("Cache warm-up loaded navigation", "elapsed", time.Since(start))
+	}
+
+	type job struct {
+		clientType string
+		nodeType   string
+	}
+	nodeTypes := b.NodeTypes(ctx)
+	var jobs []job
+	for _, clientType := range nodeTypes.ClientTypes() {
+		for _, nodeType := range nodeTypes.Types(clientType) {
+			jobs = append(jobs, job{clientType: clientType, nodeType: nodeType})
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		done   int
+		failed int
+		wg     sync.WaitGroup
+	)
+	queue := make(chan job)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				pageStart := time.Now()
+				page, err := b.getNodeGraphPageData(ctx, j.clientType, j.nodeType)
+
+				mu.Lock()
+				done++
+				if err != nil {
+					failed++
+				}
+				progress := done
+				mu.Unlock()
+
+				name := nodeGraphPageName(j.clientType, j.nodeType)
+				if err != nil {
+					if ctx.Err() == nil {
+						utils.Error("Cache warm-up failed to load node page", "page", name, "done", progress, "total", len(jobs), "error", err)
+					}
+					continue
+				}
+				utils.Info("Cache warm-up progress", "page", name, "nodes", len(page.Nodes), "done", progress, "total", len(jobs), "elapsed", time.Since(pageStart))
+			}
+		}()
+	}
+
+	for _, j := range jobs {
+		select {
+		case queue <- j:
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		utils.Info("Cache warm-up canceled", "done", done, "total", len(jobs))
+		return err
+	}
+
+	utils.Info("Cache warm-up completed", "pages", len(jobs), "failed", failed, "elapsed", time.Since(start))
+	return nil
+}
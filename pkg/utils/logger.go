@@ -4,9 +4,11 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 )
 
-var logger *slog.Logger
+// logger 当前的日志实例，并发的日志调用无需加锁读取
+var logger atomic.Pointer[slog.Logger]
 
 // InitLogger 初始化日志系统
 func InitLogger() error {
@@ -37,21 +39,23 @@ func InitLogger() error {
 	handler := slog.NewTextHandler(logFile, &slog.HandlerOptions{
 		Level: level,
 	})
-	logger = slog.New(handler)
+	logger.Store(slog.New(handler))
 
 	return nil
 }
 
 // GetLogger 获取日志实例
 func GetLogger() *slog.Logger {
-	if logger == nil {
-		// 如果未初始化，创建一个默认的stderr日志器
-		handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-			Level: slog.LevelWarn,
-		})
-		logger = slog.New(handler)
+	if l := logger.Load(); l != nil {
+		return l
 	}
-	return logger
+
+	// 如果未初始化，创建一个默认的stderr日志器；并发初始化时只保留第一个
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+	})
+	logger.CompareAndSwap(nil, slog.New(handler))
+	return logger.Load()
 }
 
 // Debug 调试日志